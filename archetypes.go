@@ -0,0 +1,309 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+ARCHETYPES
+
+obstacles are described in archetypes.json instead of in code. the copy built into the game is used
+unless the -resources folder has its own archetypes.json, which replaces it completely. the code doesn't ask for
+any archetype by name, so that only has to define the ones the stages spawn.
+
+	name            what the spawning code and stages call it
+	kind            tree, rock, trap, crap or lava to be counted as one in run history, anything else is an obstacle
+	width, height   size it's drawn at
+	hitbox          {x, y, width, height} relative to its position, leave out for something you can't hit
+	behavior        any of canBeIced, solid, explodesOnDeath, low, high, invincible, causesIce, dropsItem, melts
+	hp, damage
+	explosion       snow, ice, blood, tree, rock or steam, the dots it bursts into when it dies
+	deathSound      file in resources/audio
+	sprites         files in resources/sprites
+	spriteGroup     a group from sprites.json to use as sprites instead
+	randomSprite    pick one of sprites at random, otherwise the first is used
+	hitSprite       switch to this sprite after it hurts something, like the trap closing
+	hitSound        played when it hurts something
+	fizzleSound     played when a snowball fizzles on something that melts
+	meltSound       played when something iced melts on it
+	iceSprite       drawn on top once it's been iced
+	randomFlip      mirror it half the time
+	spread          how far either side of the middle it can be placed
+	countsAsSmashed breaking it counts towards the smashed total
+*/
+
+const archetypesFilename = "archetypes.json"
+
+//go:embed archetypes.json
+var defaultArchetypes []byte
+
+var behaviorNames = map[string]uint64{
+	"canBeIced":       bCanBeIced,
+	"solid":           bSolid,
+	"explodesOnDeath": bExplodesOnDeath,
+	"low":             bLow,
+	"high":            bHigh,
+	"invincible":      bInvincible,
+	"causesIce":       bCausesIce,
+	"dropsItem":       bDropsItem,
+	"melts":           bMelts,
+}
+
+var explosionNames = map[string]uint32{
+	"snow":  dotSnow,
+	"ice":   dotIce,
+	"blood": dotBlood,
+	"tree":  dotTree,
+	"rock":  dotRock,
+	"steam": dotSteam,
+}
+
+var kindNames = map[string]uint32{
+	"tree": kindTree,
+	"rock": kindRock,
+	"trap": kindTrap,
+	"crap": kindCrap,
+	"lava": kindLava,
+}
+
+type Archetype struct {
+	Name            string       `json:"name"`
+	Kind            string       `json:"kind"`
+	Width           float32      `json:"width"`
+	Height          float32      `json:"height"`
+	Hitbox          rl.Rectangle `json:"hitbox"`
+	Behavior        []string     `json:"behavior"`
+	Hp              int32        `json:"hp"`
+	Damage          int32        `json:"damage"`
+	Explosion       string       `json:"explosion"`
+	DeathSound      string       `json:"deathSound"`
+	Sprites         []string     `json:"sprites"`
+	SpriteGroup     string       `json:"spriteGroup"`
+	RandomSprite    bool         `json:"randomSprite"`
+	HitSprite       *int32       `json:"hitSprite"`
+	HitSound        string       `json:"hitSound"`
+	FizzleSound     string       `json:"fizzleSound"`
+	MeltSound       string       `json:"meltSound"`
+	IceSprite       string       `json:"iceSprite"`
+	RandomFlip      bool         `json:"randomFlip"`
+	Spread          int32        `json:"spread"`
+	CountsAsSmashed bool         `json:"countsAsSmashed"`
+
+	// filled in from the fields above once loaded
+	kind          uint32
+	behavior      uint64
+	explosionKind uint32
+	sprites       []AnimSource
+	iceTexture    rl.Texture2D
+	deathSound    *Sound
+	hitSound      *Sound
+	fizzleSound   *Sound
+	meltSound     *Sound
+}
+
+var archetypes = map[string]*Archetype{}
+
+/* reads the definitions, but not the sprites and sounds they point at, so it works without a window */
+func loadArchetypes() {
+	loadDefinitions(archetypesFilename, defaultArchetypes, func(data []byte) error {
+		parsed, err := parseArchetypes(data)
+		if err == nil {
+			archetypes = parsed
+		}
+		return err
+	})
+}
+
+/* goes back to the built in archetypes, for when the ones from -resources turn out to be missing something */
+func loadBuiltInArchetypes() {
+	parsed, err := parseArchetypes(defaultArchetypes)
+	if err != nil {
+		panic(fmt.Sprintf("built in %s is broken: %v", archetypesFilename, err))
+	}
+	archetypes = parsed
+}
+
+// every definitions file from -resources that was thrown out for the built in one, for -check-assets to count
+var definitionErrors = []error{}
+
+/*
+reads a definitions file from the -resources folder, or uses the built in copy if there isn't one.
+parse should only keep what it read if it returns no error, a broken file is logged, added to definitionErrors and
+the built in copy used instead.
+*/
+func loadDefinitions(filename string, builtIn []byte, parse func(data []byte) error) {
+	path := resources.dir + filename
+	data, err := readResourceOverride(filename)
+	if err == nil {
+		err = parse(data)
+		if err == nil {
+			return
+		}
+		rl.TraceLog(rl.LogError, "Failed to load %s, using the built in one: %v", path, err)
+		definitionErrors = append(definitionErrors, fmt.Errorf("%s: %w", filename, err))
+	} else if !errors.Is(err, os.ErrNotExist) {
+		rl.TraceLog(rl.LogError, "Failed to read %s, using the built in one: %v", path, err)
+		definitionErrors = append(definitionErrors, fmt.Errorf("%s: %w", filename, err))
+	}
+	err = parse(builtIn)
+	if err != nil {
+		panic(fmt.Sprintf("built in %s is broken: %v", filename, err))
+	}
+}
+
+func parseArchetypes(data []byte) (map[string]*Archetype, error) {
+	list := []*Archetype{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	parsed := map[string]*Archetype{}
+	for _, archetype := range list {
+		if archetype.Name == "" {
+			return nil, errors.New("archetype with no name")
+		}
+		if parsed[archetype.Name] != nil {
+			return nil, fmt.Errorf("archetype %s is defined twice", archetype.Name)
+		}
+		if archetype.SpriteGroup != "" {
+			group := spriteGroups[archetype.SpriteGroup]
+			if group == nil {
+				return nil, fmt.Errorf("archetype %s has unknown sprite group %s", archetype.Name, archetype.SpriteGroup)
+			}
+			if len(archetype.Sprites) > 0 {
+				return nil, fmt.Errorf("archetype %s has both sprites and a sprite group", archetype.Name)
+			}
+			archetype.Sprites = group.Sprites
+		}
+		if len(archetype.Sprites) == 0 {
+			return nil, fmt.Errorf("archetype %s has no sprites", archetype.Name)
+		}
+		if archetype.HitSprite != nil && (*archetype.HitSprite < 0 || int(*archetype.HitSprite) >= len(archetype.Sprites)) {
+			return nil, fmt.Errorf("archetype %s has no sprite %d", archetype.Name, *archetype.HitSprite)
+		}
+		if archetype.Spread < 0 {
+			return nil, fmt.Errorf("archetype %s has a negative spread", archetype.Name)
+		}
+		archetype.kind = kindObstacle
+		if kind, ok := kindNames[archetype.Kind]; ok {
+			archetype.kind = kind
+		}
+		archetype.behavior = bExists
+		for _, name := range archetype.Behavior {
+			flag, ok := behaviorNames[name]
+			if !ok {
+				return nil, fmt.Errorf("archetype %s has unknown behavior %s", archetype.Name, name)
+			}
+			archetype.behavior |= flag
+		}
+		if archetype.Explosion != "" {
+			explosionKind, ok := explosionNames[archetype.Explosion]
+			if !ok {
+				return nil, fmt.Errorf("archetype %s has unknown explosion %s", archetype.Name, archetype.Explosion)
+			}
+			archetype.explosionKind = explosionKind
+		}
+		// stays blank without a window, but the count still has to match for the random picks
+		archetype.sprites = make([]AnimSource, len(archetype.Sprites))
+		parsed[archetype.Name] = archetype
+	}
+	return parsed, nil
+}
+
+/* loads everything the archetypes draw and play, needs the window and audio device */
+func loadArchetypeResources() {
+	for _, archetype := range archetypes {
+		archetype.sprites = makeAnimSources(archetype.Sprites)
+		if archetype.IceSprite != "" {
+			archetype.iceTexture = makeAnimSources([]string{archetype.IceSprite})[0].texture
+		}
+		if archetype.DeathSound != "" {
+			archetype.deathSound = loadSound(archetype.DeathSound, soundCategorySfx)
+		}
+		if archetype.HitSound != "" {
+			archetype.hitSound = loadSound(archetype.HitSound, soundCategorySfx)
+		}
+		if archetype.FizzleSound != "" {
+			archetype.fizzleSound = loadSound(archetype.FizzleSound, soundCategorySfx)
+		}
+		if archetype.MeltSound != "" {
+			archetype.meltSound = loadSound(archetype.MeltSound, soundCategorySfx)
+		}
+	}
+}
+
+/* a new entity of this archetype at x, y */
+func (archetype *Archetype) instantiate(x, y float32, spriteIndex int32, flipped bool) Entity {
+	return Entity{
+		x:             x,
+		y:             y,
+		width:         archetype.Width,
+		height:        archetype.Height,
+		hitbox:        archetype.Hitbox,
+		behavior:      archetype.behavior,
+		kind:          archetype.kind,
+		archetype:     archetype,
+		hp:            archetype.Hp,
+		damage:        archetype.Damage,
+		explosionKind: archetype.explosionKind,
+		deathSound:    archetype.deathSound,
+		iceTexture:    archetype.iceTexture,
+		flipped:       flipped,
+		anim:          AnimState{sources: archetype.sprites, activeIndex: spriteIndex},
+	}
+}
+
+/*
+places an archetype somewhere in the 300 units below y, off the path and clear of everything already there, except
+lava can go down under iced things and melt them. gives up and returns false if it can't find a spot in placementAttempts tries
+*/
+func spawnArchetype(name string, y float32, path Path, entitys *EntityPool, rng *Rng) bool {
+	archetype := archetypes[name]
+	slot, handle := entitys.alloc()
+	if slot == nil {
+		return false
+	}
+	var spriteIndex int32
+	if archetype.RandomSprite {
+		spriteIndex = rng.value(0, int32(len(archetype.sprites)-1))
+	}
+	flipped := false
+	if archetype.RandomFlip {
+		flipped = rng.value(0, 1) == 0
+	}
+	blocks := archetype.instantiate(0, 0, 0, false).blocksPath()
+	melts := archetype.behavior&bMelts != 0
+	for range placementAttempts {
+		yRand := float32(rng.value(-300, 0))
+		x, ok := placeOffPath(path, y+yRand, archetype.Spread, archetype.Hitbox, blocks, rng)
+		if !ok {
+			continue
+		}
+		newObstacle := archetype.instantiate(x, y+yRand, spriteIndex, flipped)
+		collided := false
+		for i := range entitys.count() {
+			entity := *entitys.at(i)
+			if melts && entity.hasBehavior(bIced) {
+				continue
+			}
+			box1 := rl.Rectangle{X: entity.x - 10, Y: entity.y - 25, Width: 20, Height: 50}
+			box2 := rl.Rectangle{X: newObstacle.x - 10, Y: newObstacle.y - 25, Width: 20, Height: 50}
+
+			if aabbCollisionCheck(box1, box2) {
+				collided = true
+				break
+			}
+		}
+		if !collided {
+			*slot = newObstacle
+			return true
+		}
+	}
+	entitys.release(handle.index)
+	return false
+}
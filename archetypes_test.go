@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+/* points -resources at a folder holding files, and loads the built in definitions again afterwards */
+func useResourcesOverride(t *testing.T, files map[string][]byte) {
+	dir := t.TempDir() + string(os.PathSeparator)
+	for name, data := range files {
+		if err := os.WriteFile(dir+name, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resources.dir = dir
+	definitionErrors = definitionErrors[:0]
+	t.Cleanup(func() {
+		resources.dir = ""
+		loadSpriteGroups()
+		loadArchetypes()
+		loadStages()
+		loadTracks()
+		definitionErrors = definitionErrors[:0]
+	})
+}
+
+/* the built in archetypes, leaving out the ones named */
+func archetypesWithout(t *testing.T, names ...string) []byte {
+	list := []map[string]any{}
+	if err := json.Unmarshal(defaultArchetypes, &list); err != nil {
+		t.Fatal(err)
+	}
+	list = slices.DeleteFunc(list, func(archetype map[string]any) bool {
+		return slices.Contains(names, archetype["name"].(string))
+	})
+	data, _ := json.Marshal(list)
+	return data
+}
+
+/* an override only has to define what its stages spawn */
+func TestOverrideArchetypesOnlyNeedWhatStagesSpawn(t *testing.T) {
+	onlyTrees := `[{"name": "Only Trees", "top": 3000, "spawns": [{"archetype": "tree", "cost": 50, "density": [1]}]}]`
+	useResourcesOverride(t, map[string][]byte{
+		archetypesFilename: archetypesWithout(t, "rock", "trap", "crap", "lava"),
+		stagesFilename:     []byte(onlyTrees),
+	})
+	loadArchetypes()
+	loadStages()
+	if len(archetypes) != 1 || archetypes["tree"] == nil {
+		t.Errorf("loaded archetypes %v, want only the overridden tree", slices.Sorted(maps.Keys(archetypes)))
+	}
+	if len(stageArchetypes) != 1 || stageArchetypes[0] != "tree" {
+		t.Errorf("stages spawn %v, want the overridden stages", stageArchetypes)
+	}
+	if len(definitionErrors) != 0 {
+		t.Errorf("overrides that loaded fine were counted as broken: %v", definitionErrors)
+	}
+}
+
+/* an override missing something the built in stages spawn is thrown out instead of the built in stages */
+func TestOverrideArchetypesMissingWhatStagesSpawn(t *testing.T) {
+	useResourcesOverride(t, map[string][]byte{
+		archetypesFilename: archetypesWithout(t, "lava"),
+	})
+	loadArchetypes()
+	loadStages()
+	if archetypes["lava"] == nil {
+		t.Errorf("built in stages spawn lava but the archetypes without it were kept")
+	}
+	if !slices.Contains(stageArchetypes, "lava") {
+		t.Errorf("stages spawn %v, want the built in stages", stageArchetypes)
+	}
+	if len(definitionErrors) != 1 || !strings.HasPrefix(definitionErrors[0].Error(), archetypesFilename) {
+		t.Errorf("definition errors %v, want the thrown out archetypes", definitionErrors)
+	}
+}
+
+/* a definitions file that's thrown out for the built in copy has to be counted, so -check-assets fails */
+func TestBrokenDefinitionsAreCounted(t *testing.T) {
+	for _, filename := range definitionFilenames {
+		t.Run(filename, func(t *testing.T) {
+			useResourcesOverride(t, map[string][]byte{filename: []byte(`[{"name": `)})
+			loadSpriteGroups()
+			loadArchetypes()
+			loadStages()
+			loadTracks()
+			if len(definitionErrors) != 1 || !strings.HasPrefix(definitionErrors[0].Error(), filename) {
+				t.Errorf("definition errors %v, want just %s", definitionErrors, filename)
+			}
+		})
+	}
+}
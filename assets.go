@@ -0,0 +1,165 @@
+package main
+
+import (
+	"embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+ASSETS
+
+everything in resources/ is built into the executable, so the game runs the same from wherever it's started.
+a folder given with -resources or $ICEDPINES_RESOURCES is looked in first, file by file, so new art, sounds and
+definition files can be tried out without rebuilding. it's laid out like resources/, anything it doesn't have comes
+from the built in copy.
+*/
+
+const resourcesDirEnv = "ICEDPINES_RESOURCES"
+
+//go:embed resources
+var embeddedResources embed.FS
+
+// files music streams are still reading from, kept so they're never freed out from under them
+var streamedResources = [][]byte{}
+
+// every file asked for by its path in resources/, whether or not it could be loaded, for -check-assets
+var resourcesUsed = map[string]bool{}
+
+/* sets the override folder from the -resources flag, or the environment if that's empty. none at all is fine */
+func initResourcesDir(flagValue string) {
+	dir := flagValue
+	if dir == "" {
+		dir = os.Getenv(resourcesDirEnv)
+	}
+	if dir == "" {
+		resources.dir = ""
+		return
+	}
+	dir, _ = filepath.Abs(dir)
+	resources.dir = dir + string(filepath.Separator)
+	rl.TraceLog(rl.LogInfo, "Using resources from %s over the built in ones", resources.dir)
+}
+
+/* reads name from the override folder, or os.ErrNotExist if there isn't one or it doesn't have it */
+func readResourceOverride(name string) ([]byte, error) {
+	if resources.dir == "" {
+		return nil, os.ErrNotExist
+	}
+	return os.ReadFile(resources.dir + filepath.FromSlash(name))
+}
+
+/* reads name, like "sprites/tree1.png", from the override folder if it's there and the built in copy otherwise */
+func readResource(name string) ([]byte, error) {
+	data, err := readResourceOverride(name)
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = embeddedResources.ReadFile("resources/" + name)
+	}
+	// the loaders can't be handed nothing at all
+	if err == nil && len(data) == 0 {
+		err = errors.New("file is empty")
+	}
+	return data, err
+}
+
+/*
+the loaders below log anything that can't be read and hand back an empty one, which draws or plays as nothing.
+without a window they only note down what was asked for
+*/
+
+func loadTextureResource(name string) rl.Texture2D {
+	resourcesUsed[name] = true
+	if headless {
+		return rl.Texture2D{}
+	}
+	data, err := readResource(name)
+	if err != nil {
+		rl.TraceLog(rl.LogWarning, "Failed to read %s: %v", name, err)
+		return rl.Texture2D{}
+	}
+	image := rl.LoadImageFromMemory(filepath.Ext(name), data, int32(len(data)))
+	texture := rl.LoadTextureFromImage(image)
+	rl.UnloadImage(image)
+	return texture
+}
+
+func loadFontResource(name string, size int32, runes []rune) rl.Font {
+	resourcesUsed[name] = true
+	if headless {
+		return rl.Font{}
+	}
+	data, err := readResource(name)
+	if err == nil {
+		err = checkFontData(data)
+	}
+	if err != nil {
+		rl.TraceLog(rl.LogWarning, "Failed to read %s: %v", name, err)
+		return rl.GetFontDefault()
+	}
+	return rl.LoadFontFromMemory(filepath.Ext(name), data, size, runes)
+}
+
+func loadSoundResource(name string) rl.Sound {
+	resourcesUsed[name] = true
+	if headless {
+		return rl.Sound{}
+	}
+	data, err := readResource(name)
+	if err != nil {
+		rl.TraceLog(rl.LogWarning, "Failed to read %s: %v", name, err)
+		return rl.Sound{}
+	}
+	wave := rl.LoadWaveFromMemory(filepath.Ext(name), data, int32(len(data)))
+	sound := rl.LoadSoundFromWave(wave)
+	rl.UnloadWave(wave)
+	return sound
+}
+
+/*
+raylib reads fonts with stb_truetype, which believes whatever table directory it's handed, so a file that isn't a
+font sends it reading off the end. this makes sure the directory and every table in it fit in the file first
+*/
+func checkFontData(data []byte) error {
+	if len(data) < 12 {
+		return errors.New("too short to be a font")
+	}
+	switch string(data[:4]) {
+	case "\x00\x01\x00\x00", "OTTO", "true":
+	default:
+		return errors.New("isn't a font")
+	}
+	tables := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*tables {
+		return errors.New("table directory is cut off")
+	}
+	for i := range tables {
+		record := data[12+16*i:]
+		offset := binary.BigEndian.Uint32(record[8:])
+		length := binary.BigEndian.Uint32(record[12:])
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return fmt.Errorf("%s table is cut off", strings.TrimSpace(string(record[:4])))
+		}
+	}
+	return nil
+}
+
+/* a stream decodes as it plays, so unlike the others its file is held onto for good */
+func loadMusicResource(name string) rl.Music {
+	resourcesUsed[name] = true
+	if headless {
+		return rl.Music{}
+	}
+	data, err := readResource(name)
+	if err != nil {
+		rl.TraceLog(rl.LogWarning, "Failed to read %s: %v", name, err)
+		return rl.Music{}
+	}
+	streamedResources = append(streamedResources, data)
+	return rl.LoadMusicStreamFromMemory(filepath.Ext(name), data, int32(len(data)))
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+AUDIO
+
+every sound and stream is registered with a category when it's loaded, and categories are paused, resumed and
+turned up or down together. that's how the pause menu stops the game's sounds without touching its own clicks.
+
+each sound gets a few voices that share its samples, so an effect can overlap itself instead of cutting the last
+one off. sfx play at a slightly different pitch and volume every time so repeats don't sound pasted, and a
+cooldown keeps a sound from being retriggered faster than anyone could hear it.
+
+sounds from things in the world are played from the camera's point of view, see cameraProjectSound.
+*/
+
+const soundCategoryUI int32 = 0
+const soundCategorySfx int32 = 1
+const soundCategoryMusic int32 = 2
+const soundCategoryLoop int32 = 3
+const soundCategoryCount int32 = 4
+
+const sfxVoiceCount = 4
+const sfxPitchVariation float32 = 0.06
+const sfxVolumeVariation float32 = 0.15
+
+// stops the same sound started twice in a frame from phasing into one loud one
+const sfxCooldown float64 = 0.03
+
+// how far off center a world sound can be panned, all the way would cut it out of one ear
+const soundPanWidth float32 = 0.8
+
+type Sound struct {
+	filename        string
+	category        int32
+	voices          []rl.Sound // the first owns the samples, the rest are aliases of it
+	nextVoice       int
+	cooldown        float64 // seconds after playing before it can play again
+	lastPlayed      float64
+	pitchVariation  float32 // how far either way from normal it can be played, as a fraction
+	volumeVariation float32
+}
+
+type Stream struct {
+	music    rl.Music
+	category int32
+	volume   float32 // what the game asked for, before the category volume
+}
+
+type Audio struct {
+	sounds   map[string]*Sound  // by filename, so nothing is loaded twice
+	streams  map[string]*Stream // by filename, and a name after it for extra layers
+	volumes  [soundCategoryCount]float32
+	listener Camera // where world sounds are heard from, set every frame
+	rng      Rng    // for the variation, on its own so playing a sound never changes a run
+}
+
+var audio = Audio{
+	sounds:  map[string]*Sound{},
+	streams: map[string]*Stream{},
+	volumes: [soundCategoryCount]float32{1, 1, 1, 1},
+	rng:     newRng(randomSeed(), rngStreamCosmetic),
+}
+
+/* loads a sound from resources/audio with the defaults for its category, needs the audio device */
+func loadSound(filename string, category int32) *Sound {
+	if sound, ok := audio.sounds[filename]; ok {
+		return sound
+	}
+	sound := &Sound{filename: filename, category: category}
+	sound.voices = []rl.Sound{loadSoundResource("audio/" + filename)}
+	// a sound that failed to load has nothing to alias
+	if category == soundCategorySfx && sound.voices[0].Stream.Buffer != nil {
+		for range sfxVoiceCount - 1 {
+			sound.voices = append(sound.voices, rl.LoadSoundAlias(sound.voices[0]))
+		}
+	}
+	if category == soundCategorySfx {
+		sound.pitchVariation = sfxPitchVariation
+		sound.volumeVariation = sfxVolumeVariation
+		sound.cooldown = sfxCooldown
+	}
+	sound.lastPlayed = -sound.cooldown
+	audio.sounds[filename] = sound
+	return sound
+}
+
+/* loads a music stream from resources/audio, needs the audio device */
+func loadStream(filename string, category int32) rl.Music {
+	return loadStreamNamed(filename, filename, category)
+}
+
+/* another stream of a file, to play as a separate layer alongside the one loadStream gives */
+func loadStreamLayer(filename string, layer string, category int32) rl.Music {
+	return loadStreamNamed(filename+" "+layer, filename, category)
+}
+
+func loadStreamNamed(name string, filename string, category int32) rl.Music {
+	if stream, ok := audio.streams[name]; ok {
+		return stream.music
+	}
+	stream := &Stream{music: loadMusicResource("audio/" + filename), category: category, volume: 1}
+	audio.streams[name] = stream
+	backend.setMusicVolume(stream.music, 1)
+	return stream.music
+}
+
+func (sound *Sound) play() {
+	sound.playPanned(1, 0)
+}
+
+/* plays the sound as heard by the listener from x, y in the world */
+func (sound *Sound) playAt(x, y float32) {
+	volume, pan := cameraProjectSound(audio.listener, x, y)
+	sound.playPanned(volume, pan)
+}
+
+/*
+starts the sound on its next voice at volume, panned from -1 for left to 1 for right, unless it's still cooling down.
+does nothing for a sound that was never loaded
+*/
+func (sound *Sound) playPanned(volume float32, pan float32) {
+	if sound == nil || volume <= 0 {
+		return
+	}
+	now := rl.GetTime()
+	if now-sound.lastPlayed < sound.cooldown {
+		return
+	}
+	sound.lastPlayed = now
+	voice := sound.voices[sound.nextVoice]
+	sound.nextVoice = (sound.nextVoice + 1) % len(sound.voices)
+	vary := func(amount float32) float32 {
+		return 1 + amount*float32(audio.rng.value(-1000, 1000))/1000
+	}
+	rl.SetSoundPitch(voice, vary(sound.pitchVariation))
+	rl.SetSoundVolume(voice, min(1, volume*audio.volumes[sound.category]*vary(sound.volumeVariation)))
+	// raylib pans from 1 for left to 0 for right
+	rl.SetSoundPan(voice, 0.5-pan*soundPanWidth/2)
+	rl.PlaySound(voice)
+}
+
+func (sound *Sound) stop() {
+	if sound == nil {
+		return
+	}
+	for _, voice := range sound.voices {
+		rl.StopSound(voice)
+	}
+}
+
+func (sound *Sound) isPlaying() bool {
+	if sound == nil {
+		return false
+	}
+	for _, voice := range sound.voices {
+		if rl.IsSoundPlaying(voice) {
+			return true
+		}
+	}
+	return false
+}
+
+/* pauses every voice and stream in category that's playing */
+func (audio *Audio) pauseCategory(category int32) {
+	for _, sound := range audio.sounds {
+		if sound.category != category {
+			continue
+		}
+		for _, voice := range sound.voices {
+			rl.PauseSound(voice)
+		}
+	}
+	for _, stream := range audio.streams {
+		if stream.category == category {
+			rl.PauseMusicStream(stream.music)
+		}
+	}
+}
+
+/* resumes everything in category that was paused, anything stopped stays stopped */
+func (audio *Audio) resumeCategory(category int32) {
+	for _, sound := range audio.sounds {
+		if sound.category != category {
+			continue
+		}
+		for _, voice := range sound.voices {
+			rl.ResumeSound(voice)
+		}
+	}
+	for _, stream := range audio.streams {
+		if stream.category == category {
+			rl.ResumeMusicStream(stream.music)
+		}
+	}
+}
+
+/* scales everything in category, sounds pick it up the next time they play */
+func (audio *Audio) setCategoryVolume(category int32, volume float32) {
+	audio.volumes[category] = volume
+	for _, stream := range audio.streams {
+		if stream.category == category {
+			rl.SetMusicVolume(stream.music, stream.volume*volume)
+		}
+	}
+}
+
+/* sets a stream's own volume, which its category volume is applied on top of */
+func (audio *Audio) setStreamVolume(music rl.Music, volume float32) {
+	for _, stream := range audio.streams {
+		if stream.music.Stream.Buffer == music.Stream.Buffer {
+			stream.volume = volume
+			rl.SetMusicVolume(music, volume*audio.volumes[stream.category])
+			return
+		}
+	}
+	rl.SetMusicVolume(music, volume)
+}
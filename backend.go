@@ -0,0 +1,97 @@
+package main
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+everything update needs from the outside world. the simulation only talks to sound, music,
+input and the screen through here, so it can run with no window or audio device at all.
+*/
+type Backend interface {
+	pollInput(input *Input)
+	draw(game Game)
+
+	playSound(sound *Sound)
+	playSoundAt(sound *Sound, x, y float32)
+	setListener(camera Camera)
+	stopSound(sound *Sound)
+	isSoundPlaying(sound *Sound) bool
+	pauseCategory(category int32)
+	resumeCategory(category int32)
+	setCategoryVolume(category int32, volume float32)
+
+	playMusic(music rl.Music)
+	stopMusic(music rl.Music)
+	pauseMusic(music rl.Music)
+	resumeMusic(music rl.Music)
+	updateMusic(music rl.Music)
+	isMusicPlaying(music rl.Music) bool
+	setMusicVolume(music rl.Music, volume float32)
+	setMusicPitch(music rl.Music, pitch float32)
+	musicTimePlayed(music rl.Music) float32
+	seekMusic(music rl.Music, position float32)
+	setMasterVolume(volume float32)
+}
+
+var backend Backend = raylibBackend{}
+
+/* the real thing, needs rl.InitWindow and rl.InitAudioDevice first */
+type raylibBackend struct{}
+
+func (raylibBackend) pollInput(input *Input)                 { updateInput(input) }
+func (raylibBackend) draw(game Game)                         { draw(game) }
+func (raylibBackend) playSound(sound *Sound)                 { sound.play() }
+func (raylibBackend) playSoundAt(sound *Sound, x, y float32) { sound.playAt(x, y) }
+func (raylibBackend) setListener(camera Camera)              { audio.listener = camera }
+func (raylibBackend) stopSound(sound *Sound)                 { sound.stop() }
+func (raylibBackend) isSoundPlaying(sound *Sound) bool       { return sound.isPlaying() }
+func (raylibBackend) pauseCategory(category int32)           { audio.pauseCategory(category) }
+func (raylibBackend) resumeCategory(category int32)          { audio.resumeCategory(category) }
+func (raylibBackend) playMusic(music rl.Music)               { rl.PlayMusicStream(music) }
+func (raylibBackend) stopMusic(music rl.Music)               { rl.StopMusicStream(music) }
+func (raylibBackend) pauseMusic(music rl.Music)              { rl.PauseMusicStream(music) }
+func (raylibBackend) resumeMusic(music rl.Music)             { rl.ResumeMusicStream(music) }
+func (raylibBackend) updateMusic(music rl.Music)             { rl.UpdateMusicStream(music) }
+func (raylibBackend) isMusicPlaying(music rl.Music) bool     { return rl.IsMusicStreamPlaying(music) }
+func (raylibBackend) setMusicVolume(music rl.Music, volume float32) {
+	audio.setStreamVolume(music, volume)
+}
+func (raylibBackend) setMusicPitch(music rl.Music, pitch float32) {
+	rl.SetMusicPitch(music, pitch)
+}
+func (raylibBackend) musicTimePlayed(music rl.Music) float32 {
+	return rl.GetMusicTimePlayed(music)
+}
+func (raylibBackend) seekMusic(music rl.Music, position float32) {
+	rl.SeekMusicStream(music, position)
+}
+func (raylibBackend) setCategoryVolume(category int32, volume float32) {
+	audio.setCategoryVolume(category, volume)
+}
+func (raylibBackend) setMasterVolume(volume float32) { rl.SetMasterVolume(volume) }
+
+/* does nothing, for running the simulation with no window or audio device */
+type headlessBackend struct{}
+
+func (headlessBackend) pollInput(input *Input)                           { *input = Input{} }
+func (headlessBackend) draw(game Game)                                   {}
+func (headlessBackend) playSound(sound *Sound)                           {}
+func (headlessBackend) playSoundAt(sound *Sound, x, y float32)           {}
+func (headlessBackend) setListener(camera Camera)                        {}
+func (headlessBackend) stopSound(sound *Sound)                           {}
+func (headlessBackend) isSoundPlaying(sound *Sound) bool                 { return false }
+func (headlessBackend) pauseCategory(category int32)                     {}
+func (headlessBackend) resumeCategory(category int32)                    {}
+func (headlessBackend) playMusic(music rl.Music)                         {}
+func (headlessBackend) stopMusic(music rl.Music)                         {}
+func (headlessBackend) pauseMusic(music rl.Music)                        {}
+func (headlessBackend) resumeMusic(music rl.Music)                       {}
+func (headlessBackend) updateMusic(music rl.Music)                       {}
+func (headlessBackend) isMusicPlaying(music rl.Music) bool               { return false }
+func (headlessBackend) setMusicVolume(music rl.Music, volume float32)    {}
+func (headlessBackend) setCategoryVolume(category int32, volume float32) {}
+func (headlessBackend) setMusicPitch(music rl.Music, pitch float32)      {}
+func (headlessBackend) musicTimePlayed(music rl.Music) float32           { return 0 }
+func (headlessBackend) seekMusic(music rl.Music, position float32)       {}
+func (headlessBackend) setMasterVolume(volume float32)                   {}
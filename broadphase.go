@@ -0,0 +1,136 @@
+package main
+
+import (
+	"math/bits"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+BROADPHASE
+
+the hill is a long thin strip, so entities are only bucketed by altitude. each cell keeps a bitset of
+the slots whose hitbox reaches into it, and a query ORs together the cells a hitbox covers. anything
+that overlaps in y shares at least one cell, so the narrow phase still sees every pair that could touch.
+cells are counted down from a little above the camera, anything past either end lands in the end cells.
+*/
+
+const broadphaseCellHeight float32 = 100
+const broadphaseCellCount int32 = 64
+const broadphaseTopMargin float32 = 200 // room above the camera for things that have just been passed
+
+type SlotSet []uint64
+
+func makeSlotSet(slotCount int) SlotSet {
+	return make(SlotSet, (slotCount+63)/64)
+}
+
+func (set SlotSet) add(index int32) {
+	set[index/64] |= 1 << (index % 64)
+}
+
+func (set SlotSet) remove(index int32) {
+	set[index/64] &^= 1 << (index % 64)
+}
+
+func (set SlotSet) clear() {
+	clear(set)
+}
+
+/* first slot in the set at or after from, -1 if there isn't one */
+func (set SlotSet) next(from int32) int32 {
+	word := from / 64
+	if word >= int32(len(set)) {
+		return -1
+	}
+	bitsLeft := set[word] &^ (1<<(from%64) - 1)
+	for {
+		if bitsLeft != 0 {
+			return word*64 + int32(bits.TrailingZeros64(bitsLeft))
+		}
+		word += 1
+		if word >= int32(len(set)) {
+			return -1
+		}
+		bitsLeft = set[word]
+	}
+}
+
+type Broadphase struct {
+	topY       float32
+	cells      [broadphaseCellCount]SlotSet
+	first      []int32 // first and last cell each slot was filed under, -1 for unfiled
+	last       []int32
+	candidates SlotSet // scratch for query, only valid until the next one
+	bruteForce bool    // skip the grid and hand back every slot, for checking against the old loop
+}
+
+func (broadphase *Broadphase) cell(y float32) int32 {
+	return int32(rl.Clamp((broadphase.topY-y)/broadphaseCellHeight, 0, float32(broadphaseCellCount-1)))
+}
+
+/* the cells a hitbox covers, top first */
+func (broadphase *Broadphase) cellRange(hitbox rl.Rectangle) (int32, int32) {
+	return broadphase.cell(hitbox.Y + hitbox.Height), broadphase.cell(hitbox.Y)
+}
+
+/* files every entity that exists, empty slots never collide so they're left out */
+func (broadphase *Broadphase) build(entitys *EntityPool, cameraY float32) {
+	slotCount := int(entitys.count())
+	if len(broadphase.first) != slotCount {
+		broadphase.first = make([]int32, slotCount)
+		broadphase.last = make([]int32, slotCount)
+		broadphase.candidates = makeSlotSet(slotCount)
+		for i := range broadphase.cells {
+			broadphase.cells[i] = makeSlotSet(slotCount)
+		}
+	}
+	broadphase.topY = cameraY + broadphaseTopMargin
+	for i := range broadphase.cells {
+		broadphase.cells[i].clear()
+	}
+	for i := range entitys.count() {
+		broadphase.first[i] = -1
+		if entitys.at(i).behavior != 0 {
+			broadphase.file(i, *entitys.at(i))
+		}
+	}
+}
+
+func (broadphase *Broadphase) file(index int32, entity Entity) {
+	first, last := broadphase.cellRange(entity.getHitbox())
+	for cell := first; cell <= last; cell++ {
+		broadphase.cells[cell].add(index)
+	}
+	broadphase.first[index] = first
+	broadphase.last[index] = last
+}
+
+/* call after an entity's position changes partway through the collision pass */
+func (broadphase *Broadphase) move(index int32, entity Entity) {
+	if broadphase.first[index] >= 0 {
+		for cell := broadphase.first[index]; cell <= broadphase.last[index]; cell++ {
+			broadphase.cells[cell].remove(index)
+		}
+	}
+	broadphase.file(index, entity)
+}
+
+/* every slot that might overlap hitbox */
+func (broadphase *Broadphase) query(hitbox rl.Rectangle) SlotSet {
+	candidates := broadphase.candidates
+	if broadphase.bruteForce {
+		for i := range candidates {
+			candidates[i] = ^uint64(0)
+		}
+		return candidates
+	}
+	candidates.clear()
+	first, last := broadphase.cellRange(hitbox)
+	for cell := first; cell <= last; cell++ {
+		for i, word := range broadphase.cells[cell] {
+			candidates[i] |= word
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+)
+
+/* a run on seed with every slot around the player filled with a mix of obstacles, skiers and snowballs */
+func fullyLoadedGame(seed uint64) Game {
+	game := Game{}
+	game.chosenSeed, game.seedChosen = seed, true
+	reset(&game)
+	player := game.player()
+	rng := &game.rng
+	for spawned := 0; game.entitys.live < entitysMaxCount; spawned++ {
+		y := player.y - float32(rng.value(0, int32(viewDistance)))
+		switch spawned % 8 {
+		case 0, 1:
+			spawnArchetype("tree", y, game.path, &game.entitys, rng)
+		case 2:
+			spawnArchetype("rock", y, game.path, &game.entitys, rng)
+		case 3:
+			spawnArchetype("trap", y, game.path, &game.entitys, rng)
+		case 4:
+			spawnArchetype("crap", y, game.path, &game.entitys, rng)
+		case 5:
+			addSkier(y, &game.entitys, rng)
+		default:
+			x := float32(rng.value(-int32(hillWidth)/2, int32(hillWidth)/2))
+			addSnowball(x, y, -snowballSpeed-float32(rng.value(0, 500)), &game.entitys, rng)
+		}
+	}
+	return game
+}
+
+/* a copy of game that's fine for one collision pass, going through every pair or not */
+func copyGame(game Game, bruteForce bool) Game {
+	copied := game
+	copied.entitys = game.entitys.clone()
+	copied.broadphase = Broadphase{bruteForce: bruteForce}
+	return copied
+}
+
+/* the broadphase only skips pairs that can't touch, so a run has to play out exactly the same with or without it */
+func TestBroadphaseMatchesBruteForce(t *testing.T) {
+	const frames = 1200
+	for _, seed := range testSeeds {
+		// built twice instead of copied, a copy would share the maps and slices inside the game
+		bruteForce := fullyLoadedGame(seed)
+		bruteForce.broadphase.bruteForce = true
+		broadphase := fullyLoadedGame(seed)
+		for frame := range frames {
+			update(&bruteForce)
+			update(&broadphase)
+			if hashGameState(&bruteForce) != hashGameState(&broadphase) || bruteForce.stats != broadphase.stats {
+				t.Fatalf("seed %d: broadphase changed the result on frame %d", seed, frame)
+			}
+		}
+	}
+}
+
+func benchmarkCollisions(b *testing.B, bruteForce bool) {
+	loaded := fullyLoadedGame(1)
+	for b.Loop() {
+		game := copyGame(loaded, bruteForce)
+		resolveCollisions(&game, game.player().vy)
+	}
+}
+
+func BenchmarkCollisionsEveryPair(b *testing.B) {
+	benchmarkCollisions(b, true)
+}
+
+func BenchmarkCollisionsBroadphase(b *testing.B) {
+	benchmarkCollisions(b, false)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+ASSET CHECK
+
+goes through every file loadResources would load, with no window or audio device, and decodes each one the way the
+game would. images get their size printed and sounds their length. files in resources/ that nothing loads are
+listed too, but only a file that's missing or won't decode counts as a problem, so a release can be held back on
+the exit code without every leftover sketch blocking it.
+*/
+
+// the definition files sit at the top of the -resources folder. they're checked as they're loaded instead, and any
+// that had to be swapped for the built in copy end up in definitionErrors
+var definitionFilenames = []string{archetypesFilename, stagesFilename, tracksFilename, spritesFilename}
+
+/* checks every asset and prints what it found, returns the exit code, 1 if anything's missing or broken */
+func runAssetCheck() int {
+	headless = true
+	backend = headlessBackend{}
+	// raylib logs every file it decodes to stdout, which would bury the report
+	rl.SetTraceLogLevel(rl.LogWarning)
+	loadResources()
+
+	used := []string{}
+	for name := range resourcesUsed {
+		used = append(used, name)
+	}
+	slices.Sort(used)
+
+	problems := 0
+	for _, err := range definitionErrors {
+		fmt.Printf("BROKEN  %v\n", err)
+		problems += 1
+	}
+	for _, name := range used {
+		description, err := checkAsset(name)
+		if err != nil {
+			fmt.Printf("BROKEN  %s: %v\n", name, err)
+			problems += 1
+			continue
+		}
+		fmt.Printf("ok      %-28s %s\n", name, description)
+	}
+	unused := 0
+	for _, name := range resourceFiles() {
+		if !resourcesUsed[name] && !slices.Contains(definitionFilenames, name) {
+			fmt.Printf("unused  %s\n", name)
+			unused += 1
+		}
+	}
+	fmt.Printf("%d files checked, %d broken, %d unused\n", len(used), problems, unused)
+	if problems > 0 {
+		return 1
+	}
+	return 0
+}
+
+/* reads and decodes one asset, describing it if it's fine */
+func checkAsset(name string) (string, error) {
+	data, err := readResource(name)
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png":
+		image, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		size := image.Bounds().Size()
+		return fmt.Sprintf("%dx%d", size.X, size.Y), nil
+	case ".ogg":
+		wave := rl.LoadWaveFromMemory(".ogg", data, int32(len(data)))
+		if wave.Data == nil || wave.SampleRate == 0 {
+			return "", errors.New("can't be decoded")
+		}
+		defer rl.UnloadWave(wave)
+		seconds := float32(wave.FrameCount) / float32(wave.SampleRate)
+		return fmt.Sprintf("%.2f s, %d Hz, %d channels", seconds, wave.SampleRate, wave.Channels), nil
+	case ".otf", ".ttf":
+		return checkFont(data)
+	}
+	return "", errors.New("isn't a png, ogg or otf")
+}
+
+/* a font that won't parse comes back from raylib as nothing, which the go side trips over, so that's caught here */
+func checkFont(data []byte) (description string, err error) {
+	defer func() {
+		if recover() != nil {
+			description, err = "", errors.New("can't be decoded")
+		}
+	}()
+	if err := checkFontData(data); err != nil {
+		return "", err
+	}
+	glyphs := rl.LoadFontData(data, 48, fontRunes, int32(len(fontRunes)), rl.FontDefault)
+	missing := 0
+	for _, glyph := range glyphs {
+		if glyph.Image.Width == 0 {
+			missing += 1
+		}
+	}
+	rl.UnloadFontData(glyphs)
+	if missing > 0 {
+		return "", fmt.Errorf("%d of the game's characters are blank", missing)
+	}
+	return fmt.Sprintf("%d glyphs", len(glyphs)), nil
+}
+
+/* every file in resources/, built in or in the -resources folder, by its path in there */
+func resourceFiles() []string {
+	files := []string{}
+	fs.WalkDir(embeddedResources, "resources", func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			files = append(files, strings.TrimPrefix(path, "resources/"))
+		}
+		return nil
+	})
+	if resources.dir != "" {
+		root := os.DirFS(resources.dir)
+		fs.WalkDir(root, ".", func(path string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() {
+				files = append(files, path)
+			}
+			return nil
+		})
+	}
+	slices.Sort(files)
+	return slices.Compact(files)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+CONFIG FILE
+
+what the player picks in the menus, kept as json in the data directory so it can be fixed by hand too.
+
+	controls    control name to a list of key and button names, as shown on the controls page
+	settings    volumes from 0 to 1, muted, fullscreen and vsync, see settings.go
+
+anything missing or that can't be understood is left at its default.
+*/
+
+type Config struct {
+	Controls map[string][]string `json:"controls"`
+	Settings Settings            `json:"settings"`
+}
+
+func configFilename() string {
+	return dataDir + "config.json"
+}
+
+func loadConfig() {
+	data, err := os.ReadFile(configFilename())
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		rl.TraceLog(rl.LogError, "Failed to read config: %v", err)
+		return
+	}
+	config := Config{Settings: defaultSettings()} // so anything left out keeps its default
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		rl.TraceLog(rl.LogError, "Failed to load config, using the defaults: %v", err)
+		return
+	}
+	settings = config.Settings
+	settings.MasterVolume = rl.Clamp(settings.MasterVolume, 0, 1)
+	settings.MusicVolume = rl.Clamp(settings.MusicVolume, 0, 1)
+	settings.SfxVolume = rl.Clamp(settings.SfxVolume, 0, 1)
+	for control, name := range controlNames {
+		names, ok := config.Controls[name]
+		if !ok {
+			continue
+		}
+		loaded := []Binding{}
+		for _, bindingName := range names {
+			binding, ok := parseBinding(bindingName)
+			if !ok {
+				rl.TraceLog(rl.LogWarning, "Config has unknown key %s for %s", bindingName, name)
+				continue
+			}
+			if len(loaded) < bindingsMaxCount {
+				loaded = append(loaded, binding)
+			}
+		}
+		// a control with nothing on it couldn't be used at all, so it keeps its defaults
+		if len(loaded) > 0 {
+			bindings[control] = loaded
+		}
+	}
+}
+
+func saveConfig() {
+	config := Config{Controls: map[string][]string{}, Settings: settings}
+	for control, name := range controlNames {
+		for _, binding := range bindings[control] {
+			config.Controls[name] = append(config.Controls[name], bindingNames[binding])
+		}
+	}
+	data, err := json.MarshalIndent(config, "", "\t")
+	if err == nil {
+		err = writeFileAtomic(configFilename(), data)
+	}
+	if err != nil {
+		rl.TraceLog(rl.LogError, "Failed to save config: %v", err)
+	}
+}
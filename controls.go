@@ -0,0 +1,340 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+CONTROLS
+
+every control is bound to a list of keys and gamepad buttons, any of which works. the controls page in the menu
+rebinds them by pressing the new key, and they're saved in the config file. the left stick always steers on top of
+whatever the bindings say, see gamepad.go.
+
+a key or button can only be on one control at a time. binding it somewhere else takes it off the old control,
+unless that would leave the old control with nothing.
+*/
+
+const controlLeft int32 = 0
+const controlRight int32 = 1
+const controlUp int32 = 2
+const controlDown int32 = 3
+const controlAction int32 = 4
+const controlPause int32 = 5
+const controlMute int32 = 6
+const controlCount int32 = 7
+
+// enough for the default action keys and buttons
+const bindingsMaxCount = 8
+
+// names used in the config file
+var controlNames = [controlCount]string{"left", "right", "up", "down", "action", "pause", "mute"}
+
+// names shown on the controls page
+var controlLabels = [controlCount]string{"Left", "Right", "Up", "Down", "Action", "Pause", "Mute"}
+
+type Binding struct {
+	pad  bool  // a gamepad button on any pad, otherwise a key
+	code int32 // rl.Key* or rl.GamepadButton*
+}
+
+var bindings = defaultBindings()
+
+func defaultBindings() [controlCount][]Binding {
+	key := func(code int32) Binding { return Binding{code: code} }
+	button := func(code int32) Binding { return Binding{pad: true, code: code} }
+	return [controlCount][]Binding{
+		controlLeft:   {key(rl.KeyLeft), key(rl.KeyA), button(rl.GamepadButtonLeftFaceLeft)},
+		controlRight:  {key(rl.KeyRight), key(rl.KeyD), button(rl.GamepadButtonLeftFaceRight)},
+		controlUp:     {key(rl.KeyUp), key(rl.KeyW), button(rl.GamepadButtonLeftFaceUp)},
+		controlDown:   {key(rl.KeyDown), key(rl.KeyS), button(rl.GamepadButtonLeftFaceDown)},
+		controlAction: {key(rl.KeySpace), key(rl.KeyEnter), key(rl.KeyZ), key(rl.KeyX), button(rl.GamepadButtonRightFaceDown), button(rl.GamepadButtonRightFaceRight), button(rl.GamepadButtonRightFaceLeft), button(rl.GamepadButtonRightFaceUp)},
+		controlPause:  {key(rl.KeyEscape), button(rl.GamepadButtonMiddleRight)},
+		controlMute:   {key(rl.KeyM), button(rl.GamepadButtonMiddleLeft)},
+	}
+}
+
+// what each key and button is called on screen and in the config file, only what the font can draw
+var bindingNames = makeBindingNames()
+
+func makeBindingNames() map[Binding]string {
+	names := map[Binding]string{
+		{code: rl.KeySpace}:        "SPACE",
+		{code: rl.KeyEscape}:       "ESCAPE",
+		{code: rl.KeyEnter}:        "ENTER",
+		{code: rl.KeyTab}:          "TAB",
+		{code: rl.KeyBackspace}:    "BACKSPACE",
+		{code: rl.KeyInsert}:       "INSERT",
+		{code: rl.KeyDelete}:       "DELETE",
+		{code: rl.KeyRight}:        "RIGHT",
+		{code: rl.KeyLeft}:         "LEFT",
+		{code: rl.KeyDown}:         "DOWN",
+		{code: rl.KeyUp}:           "UP",
+		{code: rl.KeyPageUp}:       "PAGE UP",
+		{code: rl.KeyPageDown}:     "PAGE DOWN",
+		{code: rl.KeyHome}:         "HOME",
+		{code: rl.KeyEnd}:          "END",
+		{code: rl.KeyCapsLock}:     "CAPS LOCK",
+		{code: rl.KeyLeftShift}:    "LEFT SHIFT",
+		{code: rl.KeyLeftControl}:  "LEFT CTRL",
+		{code: rl.KeyLeftAlt}:      "LEFT ALT",
+		{code: rl.KeyRightShift}:   "RIGHT SHIFT",
+		{code: rl.KeyRightControl}: "RIGHT CTRL",
+		{code: rl.KeyRightAlt}:     "RIGHT ALT",
+		{code: rl.KeyLeftBracket}:  "LEFT BRACKET",
+		{code: rl.KeyBackSlash}:    "BACKSLASH",
+		{code: rl.KeyRightBracket}: "RIGHT BRACKET",
+		{code: rl.KeyGrave}:        "GRAVE",
+		{code: rl.KeyApostrophe}:   "APOSTROPHE",
+		{code: rl.KeyComma}:        "COMMA",
+		{code: rl.KeyMinus}:        "MINUS",
+		{code: rl.KeyPeriod}:       "PERIOD",
+		{code: rl.KeySlash}:        "SLASH",
+		{code: rl.KeySemicolon}:    "SEMICOLON",
+		{code: rl.KeyEqual}:        "EQUAL",
+		{code: rl.KeyKpDecimal}:    "KEYPAD PERIOD",
+		{code: rl.KeyKpDivide}:     "KEYPAD SLASH",
+		{code: rl.KeyKpMultiply}:   "KEYPAD STAR",
+		{code: rl.KeyKpSubtract}:   "KEYPAD MINUS",
+		{code: rl.KeyKpAdd}:        "KEYPAD PLUS",
+		{code: rl.KeyKpEnter}:      "KEYPAD ENTER",
+
+		// xbox names, they're printed on most pads
+		{pad: true, code: rl.GamepadButtonLeftFaceUp}:     "PAD UP",
+		{pad: true, code: rl.GamepadButtonLeftFaceRight}:  "PAD RIGHT",
+		{pad: true, code: rl.GamepadButtonLeftFaceDown}:   "PAD DOWN",
+		{pad: true, code: rl.GamepadButtonLeftFaceLeft}:   "PAD LEFT",
+		{pad: true, code: rl.GamepadButtonRightFaceUp}:    "PAD Y",
+		{pad: true, code: rl.GamepadButtonRightFaceRight}: "PAD B",
+		{pad: true, code: rl.GamepadButtonRightFaceDown}:  "PAD A",
+		{pad: true, code: rl.GamepadButtonRightFaceLeft}:  "PAD X",
+		{pad: true, code: rl.GamepadButtonLeftTrigger1}:   "PAD LB",
+		{pad: true, code: rl.GamepadButtonLeftTrigger2}:   "PAD LT",
+		{pad: true, code: rl.GamepadButtonRightTrigger1}:  "PAD RB",
+		{pad: true, code: rl.GamepadButtonRightTrigger2}:  "PAD RT",
+		{pad: true, code: rl.GamepadButtonMiddleLeft}:     "PAD BACK",
+		{pad: true, code: rl.GamepadButtonMiddle}:         "PAD GUIDE",
+		{pad: true, code: rl.GamepadButtonMiddleRight}:    "PAD START",
+		{pad: true, code: rl.GamepadButtonLeftThumb}:      "PAD L3",
+		{pad: true, code: rl.GamepadButtonRightThumb}:     "PAD R3",
+	}
+	for i := range int32(26) {
+		names[Binding{code: rl.KeyA + i}] = string(rune('A' + i))
+	}
+	for i := range int32(10) {
+		names[Binding{code: rl.KeyZero + i}] = string(rune('0' + i))
+		names[Binding{code: rl.KeyKp0 + i}] = fmt.Sprintf("KEYPAD %d", i)
+	}
+	for i := range int32(12) {
+		names[Binding{code: rl.KeyF1 + i}] = fmt.Sprintf("F%d", i+1)
+	}
+	return names
+}
+
+func parseBinding(name string) (Binding, bool) {
+	for binding, bindingName := range bindingNames {
+		if bindingName == name {
+			return binding, true
+		}
+	}
+	return Binding{}, false
+}
+
+func (binding Binding) down() bool {
+	if !binding.pad {
+		return rl.IsKeyDown(binding.code)
+	}
+	for pad := range gamepadMaxCount {
+		if rl.IsGamepadAvailable(pad) && rl.IsGamepadButtonDown(pad, binding.code) {
+			return true
+		}
+	}
+	return false
+}
+
+func (binding Binding) pressed() bool {
+	if !binding.pad {
+		return rl.IsKeyPressed(binding.code)
+	}
+	for pad := range gamepadMaxCount {
+		if rl.IsGamepadAvailable(pad) && rl.IsGamepadButtonPressed(pad, binding.code) {
+			return true
+		}
+	}
+	return false
+}
+
+func controlHeld(control int32) bool {
+	return slices.ContainsFunc(bindings[control], Binding.down)
+}
+
+func controlPressed(control int32) bool {
+	return slices.ContainsFunc(bindings[control], Binding.pressed)
+}
+
+/* the first key or button with a name that went down this frame */
+func pollNewBinding() (Binding, bool) {
+	for key := rl.GetKeyPressed(); key != 0; key = rl.GetKeyPressed() {
+		if _, ok := bindingNames[Binding{code: key}]; ok {
+			return Binding{code: key}, true
+		}
+	}
+	for pad := range gamepadMaxCount {
+		if !rl.IsGamepadAvailable(pad) {
+			continue
+		}
+		for button := int32(rl.GamepadButtonLeftFaceUp); button <= rl.GamepadButtonRightThumb; button++ {
+			if rl.IsGamepadButtonPressed(pad, button) {
+				return Binding{pad: true, code: button}, true
+			}
+		}
+	}
+	return Binding{}, false
+}
+
+/*
+puts binding in slot of control, or adds it if slot is past the end. returns what to tell the player,
+which is empty if it went through without taking anything from another control
+*/
+func bindControl(control int32, slot int, binding Binding) string {
+	name := bindingNames[binding]
+	for other := range controlCount {
+		index := slices.Index(bindings[other], binding)
+		if index < 0 {
+			continue
+		}
+		if other == control {
+			if index == slot {
+				return ""
+			}
+			return fmt.Sprintf("%s is already on %s", name, controlNames[control])
+		}
+		if len(bindings[other]) == 1 {
+			return fmt.Sprintf("%s is the only binding for %s", name, controlNames[other])
+		}
+		bindings[other] = slices.Delete(bindings[other], index, index+1)
+		setControlBinding(control, slot, binding)
+		return fmt.Sprintf("%s moved from %s", name, controlNames[other])
+	}
+	setControlBinding(control, slot, binding)
+	return ""
+}
+
+func setControlBinding(control int32, slot int, binding Binding) {
+	if slot < len(bindings[control]) {
+		bindings[control][slot] = binding
+	} else {
+		bindings[control] = append(bindings[control], binding)
+	}
+}
+
+/* CONTROLS PAGE */
+
+// the row after the controls
+const controlsRowReset = controlCount
+
+func updateControlsPage(game *Game) {
+	if game.controlsListening {
+		binding, ok := pollNewBinding()
+		if !ok {
+			return
+		}
+		game.controlsListening = false
+		backend.playSound(resources.click)
+		if binding == (Binding{code: rl.KeyEscape}) {
+			// backs out instead, so escape can only ever be on pause where it starts
+			game.controlsMessage = ""
+			return
+		}
+		game.controlsMessage = bindControl(game.controlsRow, game.controlsSlot, binding)
+		saveConfig()
+		return
+	}
+
+	pressed := menuMovePressed(game)
+	if pressed.Y != 0 {
+		game.controlsRow = min(controlsRowReset, max(0, game.controlsRow+int32(pressed.Y)))
+		game.controlsSlot = 0
+		backend.playSound(resources.click)
+	} else if pressed.X != 0 && game.controlsRow < controlsRowReset {
+		// one past the end is where a new binding gets added
+		slots := min(bindingsMaxCount, len(bindings[game.controlsRow])+1)
+		game.controlsSlot = (game.controlsSlot + int(pressed.X) + slots) % slots
+		backend.playSound(resources.click)
+	}
+
+	if game.input.action {
+		backend.playSound(resources.click)
+		if game.controlsRow == controlsRowReset {
+			bindings = defaultBindings()
+			game.controlsMessage = "controls reset"
+			saveConfig()
+		} else {
+			game.controlsListening = true
+			game.controlsMessage = ""
+		}
+	} else if game.input.erase && game.controlsRow < controlsRowReset && game.controlsSlot < len(bindings[game.controlsRow]) {
+		backend.playSound(resources.click)
+		if len(bindings[game.controlsRow]) == 1 {
+			game.controlsMessage = fmt.Sprintf("%s needs at least one binding", controlNames[game.controlsRow])
+		} else {
+			bindings[game.controlsRow] = slices.Delete(bindings[game.controlsRow], game.controlsSlot, game.controlsSlot+1)
+			game.controlsSlot = min(game.controlsSlot, len(bindings[game.controlsRow])-1)
+			game.controlsMessage = ""
+			saveConfig()
+		}
+	} else if game.input.pause {
+		game.menuPage = menuPageMain
+		game.controlsMessage = ""
+		backend.playSound(resources.click)
+	}
+}
+
+func drawControlsPage(game Game) {
+	panel := rl.Rectangle{X: 24, Y: 210, Width: float32(virtualWidth) - 48, Height: 400}
+	rl.DrawRectangleRec(rl.Rectangle{X: panel.X - 4, Y: panel.Y - 4, Width: panel.Width + 8, Height: panel.Height + 8}, rl.Black)
+	rl.DrawRectangleRec(panel, rl.White)
+
+	title := "Controls"
+	drawText(title, float32(virtualWidth)/2-measureText(title)/2, panel.Y+8)
+	x := panel.X + 12
+	right := panel.X + panel.Width - 12
+	y := panel.Y + 50
+	for row := range controlsRowReset + 1 {
+		if row == game.controlsRow {
+			rl.DrawRectangleRec(rl.Rectangle{X: panel.X + 6, Y: y, Width: panel.Width - 12, Height: 26}, colorLightGrey)
+		}
+		if row == controlsRowReset {
+			drawText("Reset to defaults", x, y)
+			break
+		}
+		drawText(controlLabels[row], x, y)
+		list := bindings[row]
+		var value string
+		switch {
+		case row == game.controlsRow && game.controlsListening:
+			value = "press a key or button"
+		case row == game.controlsRow && game.controlsSlot < len(list):
+			value = fmt.Sprintf("%s  %d of %d", bindingNames[list[game.controlsSlot]], game.controlsSlot+1, len(list))
+		case row == game.controlsRow:
+			value = "add another"
+		case len(list) > 1:
+			value = fmt.Sprintf("%s +%d", bindingNames[list[0]], len(list)-1)
+		default:
+			value = bindingNames[list[0]]
+		}
+		drawText(value, right-measureText(value), y)
+		y += 30
+	}
+
+	if game.controlsMessage != "" {
+		drawText(game.controlsMessage, float32(virtualWidth)/2-measureText(game.controlsMessage)/2, panel.Y+panel.Height-96)
+	}
+	hint := "left and right pick a binding"
+	drawText(hint, float32(virtualWidth)/2-measureText(hint)/2, panel.Y+panel.Height-62)
+	hint = "action rebinds  backspace removes"
+	drawText(hint, float32(virtualWidth)/2-measureText(hint)/2, panel.Y+panel.Height-34)
+}
@@ -0,0 +1,98 @@
+package main
+
+/*
+ENTITY POOL
+
+slots live in fixed size chunks, so growing never moves an entity and pointers taken during a frame stay good.
+empty slots wait on a free list, which makes allocating and freeing O(1). the lowest indices come off it first,
+and a freed slot is the next one handed out.
+*/
+
+const entitysChunkSize int32 = 512
+const entitysMaxCount int32 = 2048 // the pool grows a chunk at a time until it reaches this
+
+/* refers to one particular entity, stops resolving once that entity is freed even if the slot gets reused */
+type EntityHandle struct {
+	index      int32
+	generation uint32
+}
+
+type EntityPool struct {
+	chunks        []*[entitysChunkSize]Entity
+	generations   []uint32 // bumped every time a slot is freed
+	free          []int32
+	maxCount      int32
+	live          int32
+	peak          int32 // most slots ever taken at once
+	spawnFailures int32 // allocations turned down because the pool was full
+}
+
+func newEntityPool(maxCount int32) EntityPool {
+	pool := EntityPool{maxCount: maxCount}
+	pool.grow()
+	return pool
+}
+
+/* number of slots, taken or not. iterate with at(i) for i below this */
+func (pool *EntityPool) count() int32 {
+	return int32(len(pool.chunks)) * entitysChunkSize
+}
+
+func (pool *EntityPool) at(index int32) *Entity {
+	return &pool.chunks[index/entitysChunkSize][index%entitysChunkSize]
+}
+
+/* only called once the free list is empty */
+func (pool *EntityPool) grow() bool {
+	first := pool.count()
+	if first+entitysChunkSize > pool.maxCount {
+		return false
+	}
+	pool.chunks = append(pool.chunks, &[entitysChunkSize]Entity{})
+	pool.generations = append(pool.generations, make([]uint32, entitysChunkSize)...)
+	for i := first + entitysChunkSize - 1; i >= first; i-- {
+		pool.free = append(pool.free, i)
+	}
+	return true
+}
+
+/* an empty slot that's now taken, nil if the pool is full and can't grow */
+func (pool *EntityPool) alloc() (*Entity, EntityHandle) {
+	if len(pool.free) == 0 && !pool.grow() {
+		pool.spawnFailures += 1
+		return nil, EntityHandle{index: -1}
+	}
+	index := pool.free[len(pool.free)-1]
+	pool.free = pool.free[:len(pool.free)-1]
+	pool.live += 1
+	pool.peak = max(pool.peak, pool.live)
+	return pool.at(index), EntityHandle{index: index, generation: pool.generations[index]}
+}
+
+func (pool *EntityPool) release(index int32) {
+	*pool.at(index) = createEmpty()
+	pool.generations[index] += 1
+	pool.free = append(pool.free, index)
+	pool.live -= 1
+}
+
+/* nil if the entity has been freed */
+func (pool *EntityPool) get(handle EntityHandle) *Entity {
+	if handle.index < 0 || handle.index >= pool.count() || pool.generations[handle.index] != handle.generation {
+		return nil
+	}
+	return pool.at(handle.index)
+}
+
+/* a copy that shares nothing with the original apart from dots */
+func (pool *EntityPool) clone() EntityPool {
+	copied := *pool
+	copied.chunks = make([]*[entitysChunkSize]Entity, len(pool.chunks))
+	for i, chunk := range pool.chunks {
+		copiedChunk := *chunk
+		copied.chunks[i] = &copiedChunk
+	}
+	copied.generations = append([]uint32{}, pool.generations...)
+	copied.free = append([]int32{}, pool.free...)
+	return copied
+}
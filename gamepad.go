@@ -0,0 +1,59 @@
+package main
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+GAMEPADS
+
+every connected pad is read each frame, so one can be plugged in or swapped mid-run without anything to set up.
+the left stick always steers, the buttons go through the bindings in controls.go like keys do.
+*/
+
+const gamepadMaxCount int32 = 4
+const gamepadStickDeadzone float32 = 0.25
+
+// pads connected last frame, to notice one going away
+var gamepadsConnected [gamepadMaxCount]bool
+
+/* adds the stick of every connected pad on top of what the bindings already put in input */
+func updateGamepadInput(input *Input) {
+	for pad := range gamepadMaxCount {
+		connected := rl.IsGamepadAvailable(pad)
+		if gamepadsConnected[pad] && !connected {
+			input.padLost = true
+		}
+		gamepadsConnected[pad] = connected
+		if !connected {
+			continue
+		}
+
+		move := rl.Vector2{
+			X: stickAxis(rl.GetGamepadAxisMovement(pad, rl.GamepadAxisLeftX)),
+			Y: stickAxis(rl.GetGamepadAxisMovement(pad, rl.GamepadAxisLeftY)),
+		}
+		if rl.Vector2Length(move) > 1 {
+			move = rl.Vector2Normalize(move)
+		}
+		// whichever is pushed further wins, so a resting stick doesn't cancel the keys out
+		if abs(move.X) > abs(input.move.X) {
+			input.move.X = move.X
+		}
+		if abs(move.Y) > abs(input.move.Y) {
+			input.move.Y = move.Y
+		}
+	}
+}
+
+/* drops the deadzone and stretches what's left back out to the full range */
+func stickAxis(value float32) float32 {
+	if abs(value) < gamepadStickDeadzone {
+		return 0
+	}
+	scaled := (abs(value) - gamepadStickDeadzone) / (1 - gamepadStickDeadzone)
+	if value < 0 {
+		return -min(1, scaled)
+	}
+	return min(1, scaled)
+}
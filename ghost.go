@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image/color"
+	"math"
+	"os"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+GHOST FILE FORMAT
+
+everything is little endian
+
+	magic    [4]byte  "IBGH"
+	version  uint16   ghostVersion
+	count    uint32   number of frames
+	frames   count * { x float32, y float32, anim uint8 }
+	checksum uint32   crc32 (IEEE) of every byte before it
+
+one frame for every frame the simulation moved forward, from the start of the run to the bottom.
+*/
+
+const ghostMagic = "IBGH"
+const ghostVersion uint16 = 1
+const ghostFrameSize = 4 + 4 + 1
+
+var ghostTint = color.RGBA{255, 255, 255, 110}
+
+type GhostFrame struct {
+	x, y float32
+	anim uint8 // index into resources.bear
+}
+
+// the path of the fastest finished run, empty if there hasn't been one
+var ghost []GhostFrame
+
+func ghostFilename() string {
+	return dataDir + "ghost"
+}
+
+func loadGhost() {
+	ghost = nil
+	data, err := os.ReadFile(ghostFilename())
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err == nil {
+		ghost, err = decodeGhost(data)
+	}
+	if err != nil {
+		rl.TraceLog(rl.LogError, "Failed to load ghost: %v", err)
+	}
+}
+
+func saveGhost(frames []GhostFrame) {
+	err := writeFileAtomic(ghostFilename(), encodeGhost(frames))
+	if err != nil {
+		rl.TraceLog(rl.LogError, "Failed to save ghost: %v", err)
+	}
+}
+
+func encodeGhost(frames []GhostFrame) []byte {
+	buffer := bytes.Buffer{}
+	buffer.WriteString(ghostMagic)
+	binary.Write(&buffer, binary.LittleEndian, ghostVersion)
+	binary.Write(&buffer, binary.LittleEndian, uint32(len(frames)))
+	for _, frame := range frames {
+		binary.Write(&buffer, binary.LittleEndian, frame.x)
+		binary.Write(&buffer, binary.LittleEndian, frame.y)
+		buffer.WriteByte(frame.anim)
+	}
+	binary.Write(&buffer, binary.LittleEndian, crc32.ChecksumIEEE(buffer.Bytes()))
+	return buffer.Bytes()
+}
+
+func decodeGhost(data []byte) ([]GhostFrame, error) {
+	headerSize := len(ghostMagic) + 2 + 4
+	if len(data) < headerSize+4 || !bytes.HasPrefix(data, []byte(ghostMagic)) {
+		return nil, errors.New("ghost file is corrupt")
+	}
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return nil, errors.New("ghost file is corrupt")
+	}
+	version := binary.LittleEndian.Uint16(body[4:])
+	if version > ghostVersion {
+		return nil, fmt.Errorf("ghost file is from a newer version (%d)", version)
+	}
+	count := int(binary.LittleEndian.Uint32(body[6:]))
+	if len(body) != headerSize+count*ghostFrameSize {
+		return nil, errors.New("ghost file is truncated")
+	}
+	frames := make([]GhostFrame, count)
+	cursor := body[headerSize:]
+	for i := range frames {
+		frames[i] = GhostFrame{
+			x:    math.Float32frombits(binary.LittleEndian.Uint32(cursor)),
+			y:    math.Float32frombits(binary.LittleEndian.Uint32(cursor[4:])),
+			anim: cursor[8],
+		}
+		cursor = cursor[ghostFrameSize:]
+	}
+	return frames, nil
+}
+
+/* called once for every frame the simulation moves forward, after the player has moved */
+func recordGhostFrame(game *Game) {
+	game.frame += 1
+	if game.titleScreen {
+		return
+	}
+	player := *game.player()
+	for game.ghostCursor < len(ghost) && ghost[game.ghostCursor].y > player.y {
+		game.ghostCursor += 1
+	}
+	if !game.finished && !game.replaying {
+		game.ghostRecording = append(game.ghostRecording, GhostFrame{x: player.x, y: player.y, anim: uint8(player.anim.activeIndex)})
+	}
+}
+
+/* seconds behind the ghost, negative when ahead. false if there's nothing to compare against */
+func ghostDelta(game Game) (float64, bool) {
+	if len(ghost) == 0 {
+		return 0, false
+	}
+	ghostDuration := float64(len(ghost)) / 60
+	if game.finished {
+		return game.stats.finishTime - ghostDuration, true
+	}
+	if game.ghostCursor >= len(ghost) {
+		return game.playTime - ghostDuration, true
+	}
+	return game.playTime - float64(game.ghostCursor)/60, true
+}
+
+func (game Game) ghostFrame() (GhostFrame, bool) {
+	index := game.frame - 1
+	if game.titleScreen || index < 0 || index >= len(ghost) {
+		return GhostFrame{}, false
+	}
+	return ghost[index], true
+}
+
+func drawGhost(game Game, frame GhostFrame) {
+	player := *game.player()
+	preProjection := rl.Rectangle{
+		X:      frame.x - player.width/2,
+		Y:      frame.y - player.height,
+		Width:  player.width,
+		Height: player.height,
+	}
+	postProjection, visible := cameraProjectRectangle(game.camera, preProjection)
+	if !visible || int(frame.anim) >= len(resources.bear) {
+		return
+	}
+	texture := resources.bear[frame.anim].texture
+	rl.DrawTexturePro(texture, rl.Rectangle{X: 0, Y: 0, Width: float32(texture.Width), Height: float32(texture.Height)}, postProjection, rl.Vector2{X: 0, Y: 0}, 0, ghostTint)
+}
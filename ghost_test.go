@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func testGhost() []GhostFrame {
+	frames := []GhostFrame{}
+	for i := range 300 {
+		frames = append(frames, GhostFrame{x: float32(i%50) * 3.5, y: 300000 - float32(i)*12.25, anim: uint8(i % 10)})
+	}
+	return frames
+}
+
+func TestDecodeGhost(t *testing.T) {
+	encoded := encodeGhost(testGhost())
+	tests := []struct {
+		name string
+		data func() []byte
+		ok   bool
+	}{
+		{"round trip", func() []byte { return encoded }, true},
+		{"flipped byte", func() []byte {
+			data := append([]byte{}, encoded...)
+			data[len(data)/2] ^= 0x40
+			return data
+		}, false},
+		{"truncated", func() []byte { return encoded[:len(encoded)-5] }, false},
+		{"truncated with a fresh checksum", func() []byte { return resealed(encoded[:len(encoded)-5]) }, false},
+		{"header only", func() []byte { return encoded[:10] }, false},
+		{"empty", func() []byte { return nil }, false},
+		{"newer version", func() []byte {
+			data := append([]byte{}, encoded...)
+			binary.LittleEndian.PutUint16(data[4:], ghostVersion+1)
+			return resealed(data)
+		}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoded, err := decodeGhost(test.data())
+			if test.ok {
+				if err != nil {
+					t.Fatalf("failed to decode: %v", err)
+				}
+				if !reflect.DeepEqual(decoded, testGhost()) {
+					t.Errorf("decoded ghost doesn't match what was encoded")
+				}
+			} else if err == nil {
+				t.Errorf("decoded without an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+HISTORY FILE FORMAT
+
+everything is little endian, and the file is only ever appended to
+
+	magic    [4]byte  "IBRH"
+	version  uint16   historyVersion of whoever created the file
+	records  { length uint16, data [length]byte, checksum uint32 }...
+
+each record's data is the fields of a RunRecord in the order fields() returns them,
+and checksum is the crc32 (IEEE) of data. new fields go on the end of fields(),
+records written before them are shorter and leave the new fields at their defaults.
+a crash halfway through appending only ever damages the last record, and loading cuts that off
+so the next record goes where it should.
+*/
+
+const historyMagic = "IBRH"
+const historyVersion uint16 = 2 // 2 added melted
+
+// number of runs shown on the stats page
+const historyRecentCount = 5
+
+type RunRecord struct {
+	endedAt    int64   // unix seconds
+	playTime   float64 // seconds from the start of the run until it ended
+	finishTime float64 // seconds until the bottom was reached, -1 if it never was
+	lowest     float32 // altitude of the player when the run ended
+	hits       int16
+	frozen     int16 // skiers hit with snowballs
+	smashed    int16 // trees and boulders destroyed
+	items      int16
+	cause      uint8 // kind of entity that dealt the last hit, kindNothing if the run was abandoned
+	seed       uint64
+	melted     int16 // iced things that ended up on lava
+}
+
+func (record *RunRecord) fields() []any {
+	return []any{
+		&record.endedAt,
+		&record.playTime,
+		&record.finishTime,
+		&record.lowest,
+		&record.hits,
+		&record.frozen,
+		&record.smashed,
+		&record.items,
+		&record.cause,
+		&record.seed,
+		&record.melted,
+	}
+}
+
+/* counters for the run in progress, bumped where each thing happens */
+type RunStats struct {
+	hits       int16
+	frozen     int16
+	smashed    int16
+	melted     int16
+	items      int16
+	cause      uint8
+	finishTime float64
+}
+
+type Lifetime struct {
+	runs     int32
+	wins     int32
+	playTime float64
+	hits     int32
+	frozen   int32
+	smashed  int32
+	items    int32
+	melted   int32
+}
+
+var history []RunRecord
+var lifetime = Lifetime{}
+
+func historyFilename() string {
+	return dataDir + "history"
+}
+
+func (lifetime *Lifetime) add(record RunRecord) {
+	lifetime.runs += 1
+	if record.finishTime >= 0 {
+		lifetime.wins += 1
+	}
+	lifetime.playTime += record.playTime
+	lifetime.hits += int32(record.hits)
+	lifetime.frozen += int32(record.frozen)
+	lifetime.smashed += int32(record.smashed)
+	lifetime.items += int32(record.items)
+	lifetime.melted += int32(record.melted)
+}
+
+func encodeRunRecord(record RunRecord) []byte {
+	buffer := bytes.Buffer{}
+	for _, field := range record.fields() {
+		binary.Write(&buffer, binary.LittleEndian, field)
+	}
+	return buffer.Bytes()
+}
+
+func decodeRunRecord(data []byte) RunRecord {
+	record := RunRecord{finishTime: -1}
+	reader := bytes.NewReader(data)
+	for _, field := range record.fields() {
+		if binary.Read(reader, binary.LittleEndian, field) != nil {
+			break
+		}
+	}
+	return record
+}
+
+func loadHistory() {
+	history = nil
+	lifetime = Lifetime{}
+	filename := historyFilename()
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		rl.TraceLog(rl.LogError, "Failed to read run history: %v", err)
+		return
+	}
+	headerSize := len(historyMagic) + 2
+	if len(data) < headerSize || !bytes.HasPrefix(data, []byte(historyMagic)) {
+		// nothing appended after a bad header could ever be read back, so start a new file instead
+		rl.TraceLog(rl.LogError, "Run history file is corrupt")
+		if renameErr := os.Rename(filename, filename+".bad"); renameErr == nil {
+			rl.TraceLog(rl.LogWarning, "Moved unreadable run history to %s.bad", filename)
+		}
+		return
+	}
+	cursor := data[headerSize:]
+	for len(cursor) > 0 {
+		if len(cursor) < 2 || len(cursor) < 2+int(binary.LittleEndian.Uint16(cursor))+4 {
+			// cut off so the next record appended lines up after the last whole one, not inside the broken one
+			rl.TraceLog(rl.LogWarning, "Run history ends with a truncated record")
+			if truncateErr := os.Truncate(filename, int64(len(data)-len(cursor))); truncateErr != nil {
+				rl.TraceLog(rl.LogError, "Failed to cut the truncated record off the run history: %v", truncateErr)
+			}
+			break
+		}
+		length := int(binary.LittleEndian.Uint16(cursor))
+		recordData := cursor[2 : 2+length]
+		checksum := binary.LittleEndian.Uint32(cursor[2+length:])
+		cursor = cursor[2+length+4:]
+		if crc32.ChecksumIEEE(recordData) != checksum {
+			rl.TraceLog(rl.LogWarning, "Skipping corrupt run history record")
+			continue
+		}
+		record := decodeRunRecord(recordData)
+		history = append(history, record)
+		lifetime.add(record)
+	}
+}
+
+func appendRunRecord(record RunRecord) {
+	history = append(history, record)
+	lifetime.add(record)
+
+	file, err := os.OpenFile(historyFilename(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		rl.TraceLog(rl.LogError, "Failed to open run history: %v", err)
+		return
+	}
+	defer file.Close()
+	buffer := bytes.Buffer{}
+	if offset, _ := file.Seek(0, io.SeekEnd); offset == 0 {
+		buffer.WriteString(historyMagic)
+		binary.Write(&buffer, binary.LittleEndian, historyVersion)
+	}
+	data := encodeRunRecord(record)
+	binary.Write(&buffer, binary.LittleEndian, uint16(len(data)))
+	buffer.Write(data)
+	binary.Write(&buffer, binary.LittleEndian, crc32.ChecksumIEEE(data))
+	_, err = file.Write(buffer.Bytes())
+	if err != nil {
+		rl.TraceLog(rl.LogError, "Failed to save run to history: %v", err)
+	}
+}
+
+/* records the run in progress, only the first call after reset does anything */
+func endRun(game *Game) {
+	if game.runRecorded || !game.keepsRecords() {
+		return
+	}
+	game.runRecorded = true
+	finishRecording(game)
+	player := *game.player()
+	finishTime := float64(-1)
+	if game.finished {
+		finishTime = game.stats.finishTime
+	}
+	record := RunRecord{
+		endedAt:    time.Now().Unix(),
+		playTime:   game.playTime,
+		finishTime: finishTime,
+		lowest:     player.y,
+		hits:       game.stats.hits,
+		frozen:     game.stats.frozen,
+		smashed:    game.stats.smashed,
+		items:      game.stats.items,
+		cause:      game.stats.cause,
+		seed:       game.seed,
+		melted:     game.stats.melted,
+	}
+	appendRunRecord(record)
+	queueLeaderboardEntry(game, record)
+}
+
+func causeName(cause uint8) string {
+	switch uint32(cause) {
+	case kindTree:
+		return "tree"
+	case kindRock:
+		return "boulder"
+	case kindTrap:
+		return "trap"
+	case kindSkier:
+		return "penguin"
+	case kindLava:
+		return "lava pit"
+	case kindObstacle:
+		return "obstacle"
+	case kindNothing:
+		return "gave up"
+	}
+	return "unknown"
+}
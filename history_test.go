@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+/* points the data directory somewhere empty for the length of the test */
+func useTempDataDir(t *testing.T) {
+	saved := dataDir
+	dataDir = t.TempDir() + string(os.PathSeparator)
+	t.Cleanup(func() { dataDir = saved })
+}
+
+/* a crash partway through writing a record mustn't swallow the runs appended after it */
+func TestHistoryAppendsAfterTruncatedRecord(t *testing.T) {
+	useTempDataDir(t)
+	appendRunRecord(RunRecord{seed: 1, finishTime: -1})
+	appendRunRecord(RunRecord{seed: 2, finishTime: -1})
+	info, _ := os.Stat(historyFilename())
+	appendRunRecord(RunRecord{seed: 3, finishTime: -1})
+	// cut the last record off halfway through
+	if err := os.Truncate(historyFilename(), info.Size()+10); err != nil {
+		t.Fatal(err)
+	}
+
+	loadHistory()
+	if len(history) != 2 {
+		t.Fatalf("loaded %d runs from the truncated file, want 2", len(history))
+	}
+	appendRunRecord(RunRecord{seed: 4, finishTime: -1})
+	loadHistory()
+	seeds := []uint64{}
+	for _, record := range history {
+		seeds = append(seeds, record.seed)
+	}
+	if len(seeds) != 3 || seeds[0] != 1 || seeds[1] != 2 || seeds[2] != 4 {
+		t.Errorf("reloaded runs with seeds %v, want [1 2 4]", seeds)
+	}
+}
+
+/* a file with a broken header is moved aside, and runs after it go into a new one */
+func TestHistoryMovesBadHeaderAside(t *testing.T) {
+	useTempDataDir(t)
+	if err := os.WriteFile(historyFilename(), []byte("not a history file"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loadHistory()
+	if len(history) != 0 {
+		t.Fatalf("loaded %d runs from a bad file", len(history))
+	}
+	if _, err := os.Stat(historyFilename() + ".bad"); err != nil {
+		t.Errorf("bad file wasn't kept: %v", err)
+	}
+	appendRunRecord(RunRecord{seed: 5, finishTime: -1})
+	loadHistory()
+	if len(history) != 1 || history[0].seed != 5 {
+		t.Errorf("reloaded %v, want the one run appended after the bad file", history)
+	}
+}
+
+/* records from before melted was added are shorter, and load with none melted */
+func TestDecodeRunRecordBeforeMelted(t *testing.T) {
+	record := RunRecord{endedAt: 1700000000, playTime: 95, finishTime: 90, lowest: -10, hits: 1, frozen: 4, smashed: 12, items: 2, cause: uint8(kindNothing), seed: 42, melted: 3}
+	data := encodeRunRecord(record)
+	if decoded := decodeRunRecord(data); decoded != record {
+		t.Errorf("decoded %+v, want %+v", decoded, record)
+	}
+	record.melted = 0
+	if decoded := decodeRunRecord(data[:len(data)-2]); decoded != record {
+		t.Errorf("decoded an old record as %+v, want %+v", decoded, record)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const boardFastest int32 = 0
+const boardLowest int32 = 1
+const boardFewestHits int32 = 2
+const boardCount int32 = 3
+
+const leaderboardSize = 10
+
+var boardNames = [boardCount]string{"Fastest finish", "Lowest altitude", "Fewest hits"}
+
+type LeaderboardEntry struct {
+	initials [3]byte
+	value    float64 // seconds, altitude or hits depending on the board, lower is always better
+	date     int64   // unix seconds
+}
+
+type Leaderboard struct {
+	count   int32
+	entries [leaderboardSize]LeaderboardEntry
+}
+
+func (board *Leaderboard) fields() []any {
+	fields := []any{&board.count}
+	for i := range board.entries {
+		entry := &board.entries[i]
+		fields = append(fields, &entry.initials, &entry.value, &entry.date)
+	}
+	return fields
+}
+
+func (board Leaderboard) qualifies(value float64) bool {
+	return board.count < leaderboardSize || value < board.entries[board.count-1].value
+}
+
+/* ties go below the entries that were there first */
+func (board *Leaderboard) insert(entry LeaderboardEntry) {
+	if !board.qualifies(entry.value) {
+		return
+	}
+	board.count = min(board.count, leaderboardSize)
+	position := board.count
+	for position > 0 && entry.value < board.entries[position-1].value {
+		position -= 1
+	}
+	last := min(board.count, leaderboardSize-1)
+	copy(board.entries[position+1:last+1], board.entries[position:last])
+	board.entries[position] = entry
+	board.count = min(board.count+1, leaderboardSize)
+}
+
+func formatBoardValue(board int32, value float64) string {
+	switch board {
+	case boardFastest:
+		return fmt.Sprintf("%d s", int32(value))
+	case boardLowest:
+		return fmt.Sprintf("%d m", int32(value/100))
+	case boardFewestHits:
+		return fmt.Sprintf("%d hits", int32(value))
+	}
+	return ""
+}
+
+/* bests from before there were leaderboards become anonymous entries */
+func seedLeaderboards(s *Scores) {
+	for i := range boardCount {
+		if s.boards[i].count > 0 {
+			return
+		}
+	}
+	anonymous := [3]byte{'-', '-', '-'}
+	if s.fastestTime >= 0 {
+		s.boards[boardFastest].insert(LeaderboardEntry{initials: anonymous, value: s.fastestTime})
+	}
+	if s.lowest < startingHeight {
+		s.boards[boardLowest].insert(LeaderboardEntry{initials: anonymous, value: float64(s.lowest)})
+	}
+	if s.fewestHits >= 0 {
+		s.boards[boardFewestHits].insert(LeaderboardEntry{initials: anonymous, value: float64(s.fewestHits)})
+	}
+}
+
+/* results of the run that just ended waiting for the player to put their initials on them */
+type PendingScore struct {
+	waiting bool
+	boards  [boardCount]bool
+	values  [boardCount]float64
+}
+
+/* checks the finished run against every board, and sends the menu to the initials page if it made one */
+func queueLeaderboardEntry(game *Game, record RunRecord) {
+	pending := PendingScore{}
+	pending.values[boardLowest] = float64(record.lowest)
+	pending.boards[boardLowest] = true
+	if record.finishTime >= 0 {
+		pending.values[boardFastest] = record.finishTime
+		pending.values[boardFewestHits] = float64(record.hits)
+		pending.boards[boardFastest] = true
+		pending.boards[boardFewestHits] = true
+	}
+	for i := range boardCount {
+		pending.boards[i] = pending.boards[i] && scores.boards[i].qualifies(pending.values[i])
+		pending.waiting = pending.waiting || pending.boards[i]
+	}
+	if !pending.waiting {
+		return
+	}
+	game.pendingScore = pending
+	game.initials = scores.lastInitials
+	game.initialsCursor = 0
+	game.afterInitials = -1
+	game.menuPage = menuPageInitials
+}
+
+func submitInitials(game *Game) {
+	if !game.pendingScore.waiting {
+		return
+	}
+	now := time.Now().Unix()
+	for i := range boardCount {
+		if game.pendingScore.boards[i] {
+			scores.boards[i].insert(LeaderboardEntry{initials: game.initials, value: game.pendingScore.values[i], date: now})
+		}
+	}
+	scores.lastInitials = game.initials
+	game.pendingScore = PendingScore{}
+	saveScores()
+}
+
+func updateInitialsPage(game *Game) {
+	pressed := menuMovePressed(game)
+	letter := &game.initials[game.initialsCursor]
+	if pressed.Y < 0 {
+		*letter = nextInitial(*letter, 1)
+		backend.playSound(resources.click)
+	} else if pressed.Y > 0 {
+		*letter = nextInitial(*letter, -1)
+		backend.playSound(resources.click)
+	} else if pressed.X < 0 && game.initialsCursor > 0 {
+		game.initialsCursor -= 1
+		backend.playSound(resources.click)
+	} else if pressed.X > 0 && game.initialsCursor < 2 {
+		game.initialsCursor += 1
+		backend.playSound(resources.click)
+	}
+	if game.input.action && game.initialsCursor < 2 {
+		game.initialsCursor += 1
+		backend.playSound(resources.click)
+	} else if game.input.action || game.input.pause {
+		submitInitials(game)
+		game.menuPage = menuPageMain
+		backend.playSound(resources.click)
+		switch game.afterInitials {
+		case menuItemNewRun:
+			reset(game)
+		case menuItemQuit:
+			game.quit = true
+		}
+	}
+}
+
+func nextInitial(letter byte, step int) byte {
+	if letter < 'A' || letter > 'Z' {
+		return 'A'
+	}
+	return byte('A' + (int(letter-'A')+step+26)%26)
+}
+
+func updateBoardsPage(game *Game) {
+	pressed := menuMovePressed(game)
+	if pressed.X > 0 {
+		game.boardPage = (game.boardPage + 1) % boardCount
+		backend.playSound(resources.click)
+	} else if pressed.X < 0 {
+		game.boardPage = (game.boardPage + boardCount - 1) % boardCount
+		backend.playSound(resources.click)
+	}
+	if game.input.action || game.input.pause {
+		game.menuPage = menuPageMain
+		backend.playSound(resources.click)
+	}
+}
+
+func drawInitialsPage(game Game) {
+	panel := rl.Rectangle{X: 24, Y: 210, Width: float32(virtualWidth) - 48, Height: 330}
+	rl.DrawRectangleRec(rl.Rectangle{X: panel.X - 4, Y: panel.Y - 4, Width: panel.Width + 8, Height: panel.Height + 8}, rl.Black)
+	rl.DrawRectangleRec(panel, rl.White)
+
+	y := panel.Y + 8
+	title := "NEW RECORD!"
+	drawText(title, float32(virtualWidth)/2-measureText(title)/2, y)
+	y += 34
+	for i := range boardCount {
+		if game.pendingScore.boards[i] {
+			drawText(boardNames[i], panel.X+12, y)
+			value := formatBoardValue(i, game.pendingScore.values[i])
+			drawText(value, panel.X+panel.Width-12-measureText(value), y)
+			y += 28
+		}
+	}
+
+	letterWidth := float32(64)
+	left := float32(virtualWidth)/2 - letterWidth*1.5
+	lettersY := panel.Y + 180
+	for i, letter := range game.initials {
+		x := left + float32(i)*letterWidth
+		str := string(rune(letter))
+		drawTextBig(str, x+letterWidth/2-measureTextBig(str)/2, lettersY)
+		if int32(i) == game.initialsCursor {
+			rl.DrawRectangleRec(rl.Rectangle{X: x + 8, Y: lettersY + 70, Width: letterWidth - 16, Height: 6}, colorLightBlue)
+		}
+	}
+	hint := "up down to pick a letter"
+	drawText(hint, float32(virtualWidth)/2-measureText(hint)/2, panel.Y+panel.Height-34)
+}
+
+func drawBoardsPage(game Game) {
+	panel := rl.Rectangle{X: 24, Y: 210, Width: float32(virtualWidth) - 48, Height: 400}
+	rl.DrawRectangleRec(rl.Rectangle{X: panel.X - 4, Y: panel.Y - 4, Width: panel.Width + 8, Height: panel.Height + 8}, rl.Black)
+	rl.DrawRectangleRec(panel, rl.White)
+
+	board := scores.boards[game.boardPage]
+	y := panel.Y + 8
+	title := fmt.Sprintf("- %s -", boardNames[game.boardPage])
+	drawText(title, float32(virtualWidth)/2-measureText(title)/2, y)
+	y += 40
+	if board.count == 0 {
+		str := "No records yet"
+		drawText(str, float32(virtualWidth)/2-measureText(str)/2, y)
+	}
+	for i := range board.count {
+		entry := board.entries[i]
+		drawText(fmt.Sprintf("%d.", i+1), panel.X+12, y)
+		drawText(string(entry.initials[:]), panel.X+60, y)
+		if entry.date != 0 {
+			drawText(time.Unix(entry.date, 0).Format("2006-01-02"), panel.X+160, y)
+		}
+		value := formatBoardValue(game.boardPage, entry.value)
+		drawText(value, panel.X+panel.Width-12-measureText(value), y)
+		y += 34
+	}
+}
@@ -1,108 +1,60 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"image/color"
 	"math"
 	"os"
-	"path/filepath"
 	"slices"
-	"unsafe"
 
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
-type Scores struct {
-	fastestTime float64
-	lowest      float32
-	fewestHits  int16
-	wins        int16
-}
-
-var scores = Scores{}
-
-func loadScores() {
-	scoreFilename := resources.dir + "scores"
-	file, err := os.Open(scoreFilename)
-	if err != nil {
-		scores = Scores{lowest: startingHeight, fastestTime: -1, fewestHits: -1, wins: 0}
-		return
-	}
-	countNeeded := unsafe.Sizeof(scores)
-	buffer := make([]uint8, countNeeded)
-	count, err := file.Read(buffer)
-	if uintptr(count) < countNeeded {
-		scores = Scores{lowest: startingHeight, fastestTime: -1, fewestHits: -1, wins: 0}
-		return
-	}
-	ptr := (*byte)(unsafe.Pointer(&scores))
-	slc := unsafe.Slice(ptr, countNeeded)
-	copy(slc, buffer)
-}
-
-func saveScores() {
-	scoreFilename := resources.dir + "scores"
-	file, err := os.Create(scoreFilename)
-	if err != nil {
-		rl.TraceLog(rl.LogError, "Failed to save scores to file")
-		return
-	}
-	ptr := (*byte)(unsafe.Pointer(&scores))
-	countNeeded := unsafe.Sizeof(scores)
-	var data []byte = unsafe.Slice(ptr, countNeeded)
-	count, err := file.Write(data)
-	if uintptr(count) < countNeeded || err != nil {
-		rl.TraceLog(rl.LogError, "Failed to save scores to file")
-	}
-}
+// bump whenever a change could make old replays play out differently
+const gameVersion = "1.5"
 
 type Resources struct {
-	dir            string
+	dir            string // the folder overriding the built in files, empty for none
 	font           rl.Font
 	fontBig        rl.Font
-	background     [1]AnimSource
-	bear           [10]AnimSource
-	crap           [6]AnimSource
-	rock           [3]AnimSource
-	health         [3]AnimSource
-	icons          [3]AnimSource
-	menu           [2]AnimSource
-	penguin        [4]AnimSource
-	penguinIce     [1]AnimSource
-	pole           [1]AnimSource
-	snowball       [2]AnimSource
-	trap           [2]AnimSource
-	trees          [2]AnimSource
-	treeIce        [1]AnimSource
-	music          rl.Music
-	musicMenu      rl.Music
+	background     []AnimSource
+	bear           []AnimSource
+	health         []AnimSource
+	icons          []AnimSource
+	menu           []AnimSource
+	penguin        []AnimSource
+	penguinIce     []AnimSource
+	pole           []AnimSource
+	snowball       []AnimSource
+	trees          []AnimSource
 	slideCenter    rl.Music
 	slideSide      rl.Music
-	boost          rl.Sound
-	click          rl.Sound
-	iceBreak       rl.Sound
-	iced           rl.Sound
-	impact         rl.Sound
-	item           rl.Sound
-	meatBreak      rl.Sound
-	meatDead       rl.Sound
-	penguinSquawk  rl.Sound
-	rockBreak      rl.Sound
-	scoop          rl.Sound
-	snowballImpact rl.Sound
-	snowballReady  rl.Sound
-	snowballThrow  rl.Sound
-	trapClosing    rl.Sound
-	treeBreak      rl.Sound
-	win            rl.Sound
+	slideBoost     rl.Music
+	boost          *Sound
+	click          *Sound
+	iceBreak       *Sound
+	iced           *Sound
+	impact         *Sound
+	item           *Sound
+	meatBreak      *Sound
+	meatDead       *Sound
+	penguinSquawk  *Sound
+	rockBreak      *Sound
+	scoop          *Sound
+	snowballImpact *Sound
+	snowballReady  *Sound
+	snowballThrow  *Sound
+	trapClosing    *Sound
+	treeBreak      *Sound
+	win            *Sound
+	textures       map[string]rl.Texture2D // everything loaded so far by filename, so nothing is loaded twice
 }
 
 var resources = Resources{}
 
 const leftAnimIndex int32 = 0
-const trapOpenAnimIndex int32 = 0
 const centerAnimIndex int32 = 1
-const trapClosedAnimIndex int32 = 1
 const rightAnimIndex int32 = 2
 const shockedAnimIndex int32 = 3
 const leftGrabAnimIndex int32 = 3
@@ -113,125 +65,67 @@ const rightGrabAnimIndex int32 = 7
 const rightThrowAnimIndex int32 = 8
 const hurtAnimIndex int32 = 9
 
-func loadResources() {
-	resources.dir, _ = filepath.Abs(filepath.Dir(os.Args[0]))
-	resources.dir += "/resources/"
+// the only characters the font has
+var fontRunes = []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!.?-:+")
 
+/* loads everything the game draws and plays. without a window it only notes down which files those are */
+func loadResources() {
 	/* FONTS */
-	runes := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!.?-:")
-	resources.font = rl.LoadFontEx(resources.dir+"Steak Melt.otf", 48, runes, int32(len(runes)))
-	resources.fontBig = rl.LoadFontEx(resources.dir+"Steak Melt.otf", 144, runes, int32(len(runes)))
-
-	/* ANIMATIONS */
-	resources.background = [1]AnimSource(makeAnimSources([]string{"background.png"}))
-	resources.bear = [10]AnimSource(makeAnimSources([]string{
-		"bearLeft.png",
-		"bearCenter.png",
-		"bearRight.png",
-		"bearLeftGrab.png",
-		"bearLeftThrow.png",
-		"bearCenterGrab.png",
-		"bearCenterThrow.png",
-		"bearRightGrab.png",
-		"bearRightThrow.png",
-		"bearHurt.png",
-	}))
-	resources.crap = [6]AnimSource(makeAnimSources([]string{"crap1.png", "crap2.png", "crap3.png", "crap4.png", "crap5.png", "crap6.png"}))
-	resources.rock = [3]AnimSource(makeAnimSources([]string{"boulder1.png", "boulder2.png", "boulder3.png"}))
-	resources.health = [3]AnimSource(makeAnimSources([]string{"healthDead.png", "healthAlive.png", "healthFrame.png"}))
-	resources.icons = [3]AnimSource(makeAnimSources([]string{"iconAltitude.png", "iconSpeed.png", "iconClock.png"}))
-	resources.menu = [2]AnimSource(makeAnimSources([]string{"menu1.png", "menu2.png"}))
-	resources.penguin = [4]AnimSource(makeAnimSources([]string{"penguinLeft.png", "penguinCenter.png", "penguinRight.png", "penguinShocked.png"}))
-	resources.penguinIce = [1]AnimSource(makeAnimSources([]string{"penguinIce.png"}))
-	resources.pole = [1]AnimSource(makeAnimSources([]string{"pole.png"}))
-	resources.snowball = [2]AnimSource(makeAnimSources([]string{"snowball1.png", "snowball2.png"}))
-	resources.trap = [2]AnimSource(makeAnimSources([]string{"trapOpen.png", "trapClosed.png"}))
-	resources.trees = [2]AnimSource(makeAnimSources([]string{"tree1.png", "tree2.png"}))
-	resources.treeIce = [1]AnimSource(makeAnimSources([]string{"treeIce.png"}))
+	resources.font = loadFontResource("Steak Melt.otf", 48, fontRunes)
+	resources.fontBig = loadFontResource("Steak Melt.otf", 144, fontRunes)
 
-	/* SOUNDS */
-	resources.music = rl.LoadMusicStream(resources.dir + "audio/music.ogg")
-	resources.musicMenu = rl.LoadMusicStream(resources.dir + "audio/musicMenu.ogg")
-	resources.boost = loadSound("boost.ogg")
-	resources.click = loadSound("click.ogg")
-	resources.iceBreak = loadSound("iceBreak.ogg")
-	resources.iced = loadSound("iced.ogg")
-	resources.impact = loadSound("impact.ogg")
-	resources.item = loadSound("item.ogg")
-	resources.meatBreak = loadSound("meatBreak.ogg")
-	resources.meatDead = loadSound("meatDead.ogg")
-	resources.penguinSquawk = loadSound("penguinSquawk.ogg")
-	resources.rockBreak = loadSound("rockBreak.ogg")
-	resources.scoop = loadSound("scoop.ogg")
-	resources.slideCenter = rl.LoadMusicStream(resources.dir + "audio/slideCenter.ogg")
-	resources.slideSide = rl.LoadMusicStream(resources.dir + "audio/slideSide.ogg")
-	resources.snowballImpact = loadSound("snowballImpact.ogg")
-	resources.snowballReady = loadSound("snowballReady.ogg")
-	resources.snowballThrow = loadSound("snowballThrow.ogg")
-	resources.trapClosing = loadSound("trapClosing.ogg")
-	resources.treeBreak = loadSound("treeBreak.ogg")
-	resources.win = loadSound("win.ogg")
+	/* SPRITES */
+	loadSpriteGroups()
+	loadSpriteResources()
 
-}
+	/* SOUNDS */
+	resources.boost = loadSound("boost.ogg", soundCategorySfx)
+	resources.click = loadSound("click.ogg", soundCategoryUI)
+	resources.iceBreak = loadSound("iceBreak.ogg", soundCategorySfx)
+	resources.iced = loadSound("iced.ogg", soundCategorySfx)
+	resources.impact = loadSound("impact.ogg", soundCategorySfx)
+	resources.item = loadSound("item.ogg", soundCategorySfx)
+	resources.meatBreak = loadSound("meatBreak.ogg", soundCategorySfx)
+	resources.meatDead = loadSound("meatDead.ogg", soundCategorySfx)
+	resources.penguinSquawk = loadSound("penguinSquawk.ogg", soundCategorySfx)
+	resources.penguinSquawk.cooldown = 0.75 // a skier keeps squawking for as long as it's stuck in front of the bear
+	resources.rockBreak = loadSound("rockBreak.ogg", soundCategorySfx)
+	resources.scoop = loadSound("scoop.ogg", soundCategorySfx)
+	resources.slideCenter = loadStream("slideCenter.ogg", soundCategoryLoop)
+	resources.slideSide = loadStream("slideSide.ogg", soundCategoryLoop)
+	resources.slideBoost = loadStreamLayer("slideSide.ogg", "boost", soundCategoryLoop)
+	resources.snowballImpact = loadSound("snowballImpact.ogg", soundCategorySfx)
+	resources.snowballReady = loadSound("snowballReady.ogg", soundCategorySfx)
+	resources.snowballThrow = loadSound("snowballThrow.ogg", soundCategorySfx)
+	resources.trapClosing = loadSound("trapClosing.ogg", soundCategorySfx)
+	resources.treeBreak = loadSound("treeBreak.ogg", soundCategorySfx)
+	resources.win = loadSound("win.ogg", soundCategoryMusic)
+
+	/* ARCHETYPES */
+	loadArchetypes()
+	loadArchetypeResources()
+	loadStages()
+	loadStageResources()
+	loadTracks()
+	loadTrackResources()
+	reportSpriteFiles()
 
-func loadSound(filename string) rl.Sound {
-	return rl.LoadSound(fmt.Sprint(resources.dir, "audio/", filename))
 }
 
+/* the game's sounds stop while the menu is open, the menu's own clicks and music carry on */
 func pauseSounds() {
-	rl.PauseSound(resources.boost)
-	// HACK never pause click
-	// rl.PauseSound(resources.click)
-	rl.PauseSound(resources.iceBreak)
-	rl.PauseSound(resources.iced)
-	rl.PauseSound(resources.impact)
-	rl.PauseSound(resources.item)
-	rl.PauseSound(resources.meatBreak)
-	rl.PauseSound(resources.meatDead)
-	rl.PauseSound(resources.penguinSquawk)
-	rl.PauseSound(resources.rockBreak)
-	rl.PauseSound(resources.scoop)
-	rl.PauseMusicStream(resources.slideCenter)
-	rl.PauseMusicStream(resources.slideSide)
-	rl.PauseSound(resources.snowballImpact)
-	rl.PauseSound(resources.snowballReady)
-	rl.PauseSound(resources.snowballThrow)
-	rl.PauseSound(resources.trapClosing)
-	rl.PauseSound(resources.treeBreak)
-	rl.PauseSound(resources.win)
+	backend.pauseCategory(soundCategorySfx)
+	backend.pauseCategory(soundCategoryLoop)
 }
 
 func resumeSounds() {
-	rl.ResumeSound(resources.boost)
-	rl.ResumeSound(resources.click)
-	rl.ResumeSound(resources.iceBreak)
-	rl.ResumeSound(resources.iced)
-	rl.ResumeSound(resources.impact)
-	rl.ResumeSound(resources.item)
-	rl.ResumeSound(resources.meatBreak)
-	rl.ResumeSound(resources.meatDead)
-	rl.ResumeSound(resources.penguinSquawk)
-	rl.ResumeSound(resources.rockBreak)
-	rl.ResumeSound(resources.scoop)
-	rl.ResumeMusicStream(resources.slideCenter)
-	rl.ResumeMusicStream(resources.slideSide)
-	rl.ResumeSound(resources.snowballImpact)
-	rl.ResumeSound(resources.snowballReady)
-	rl.ResumeSound(resources.snowballThrow)
-	rl.ResumeSound(resources.trapClosing)
-	rl.ResumeSound(resources.treeBreak)
-	rl.ResumeSound(resources.win)
+	backend.resumeCategory(soundCategorySfx)
+	backend.resumeCategory(soundCategoryLoop)
 }
 
-const windowWidth int32 = 600
-const windowHeight int32 = 800
-
 const playerWidth int32 = 50
 const playerAcceleration float32 = 200
 
-const entitysMaxCount int32 = 512
-const entitysPlayerIndex int32 = 1
-
 /* BEHAVIORS */
 const bExists uint64 = 1 << 0
 const bIced uint64 = 1 << 2
@@ -248,6 +142,21 @@ const bLow uint64 = 1 << 12
 const bHigh uint64 = 1 << 13
 const bSmashEverything uint64 = 1 << 14
 const bInvincible uint64 = 1 << 15
+const bMelts uint64 = 1 << 16 // snowballs fizzle and iced things melt on top of this
+
+/* KINDS */
+const kindNothing uint32 = 0
+const kindPlayer uint32 = 1
+const kindTree uint32 = 2
+const kindRock uint32 = 3
+const kindTrap uint32 = 4
+const kindOuterTree uint32 = 5
+const kindCrap uint32 = 6
+const kindSkier uint32 = 7
+const kindSnowball uint32 = 8
+const kindPole uint32 = 9
+const kindObstacle uint32 = 10 // anything from archetypes.json that isn't one of the others
+const kindLava uint32 = 11
 
 type Timer struct {
 	time float32
@@ -265,12 +174,14 @@ type Entity struct {
 	width, height float32 // this is purely visually for now, hitbox defined lower
 	rotationSpeed float32
 	behavior      uint64
+	kind          uint32     // what this is, only used for bookkeeping
+	archetype     *Archetype // what it was spawned from, nil for anything made in code
 	hp, hpMax     int32
 	damage        int32
 	wishSpeed     float32
 	centerX       float32 // center of where a skier wants to be
 	hitbox        rl.Rectangle
-	deathSound    rl.Sound
+	deathSound    *Sound
 	explosionKind uint32
 	attackTimer   Timer
 	invulnTimer   Timer
@@ -292,10 +203,15 @@ type Camera struct {
 }
 
 type Input struct {
-	move   rl.Vector2
-	pause  bool
-	action bool
-	mute   bool
+	move       rl.Vector2
+	pause      bool
+	action     bool
+	mute       bool
+	fullscreen bool
+	overlay    bool // F3, shows hitboxes and how full the entity pool is
+	char       rune // text typed this frame, only used by menus
+	erase      bool
+	padLost    bool // a gamepad was unplugged this frame
 }
 
 const dotNothing uint32 = 0
@@ -305,6 +221,7 @@ const dotBlood uint32 = 3
 const dotTree uint32 = 4
 const dotRock uint32 = 5
 const dotBoost uint32 = 6
+const dotSteam uint32 = 7
 
 type Dot struct {
 	x, y, z    float32 // z in this case means up and down. 0 is ground
@@ -363,41 +280,114 @@ type HealthBar struct {
 }
 
 type Game struct {
-	playTime          float64
-	skierPoints       float32
-	treePoints        float32
-	rockPoints        float32
-	trapPoints        float32
-	outerTreePoints   float32
-	crapPoints        float32
-	furthestY         float32
-	lastBarrierY      float32
-	deathTimer        Timer
-	skierTimer        Timer
-	hpShakeTimer      Timer
-	boostTimer        Timer
-	notificationTimer Timer
-	notificationText  string
-	hits              int16
-	menuSelection     int32
-	menuOpen          bool
-	quit              bool
-	finished          bool
-	muted             bool
-	musicVolume       float32
-	musicMenuVolume   float32
-	camera            Camera
-	healthBar         HealthBar
-	input             Input
-	entitys           [entitysMaxCount]Entity
+	playTime           float64
+	skierPoints        float32
+	outerTreePoints    float32
+	spawnPoints        map[string]float32 // for each archetype in stageArchetypes
+	stage              int32              // index into stages, -1 before the first update
+	path               Path
+	furthestY          float32
+	lastBarrierY       float32
+	deathTimer         Timer
+	skierTimer         Timer
+	hpShakeTimer       Timer
+	boostTimer         Timer
+	notificationTimer  Timer
+	notificationText   string
+	seed               uint64
+	chosenSeed         uint64 // used for every new run while seedChosen is set
+	seedChosen         bool
+	seedText           string
+	titleScreen        bool
+	rng                Rng // everything that changes what happens in a run
+	fxRng              Rng // particles, shake and anything else only for show
+	stats              RunStats
+	frame              int // frames the simulation has moved forward since reset
+	ghostRecording     []GhostFrame
+	ghostCursor        int // first ghost frame that's below the player
+	recording          Replay
+	recordingActive    bool
+	recordingBest      bool // the run set the fastest time, so its replay is kept for good
+	playback           Replay
+	replaying          bool
+	playbackFrame      int
+	playbackCheckpoint int
+	playbackDone       bool
+	replayMismatch     bool
+	replayMismatchTime float64
+	runRecorded        bool
+	menuSelection      int32
+	menuPage           int32
+	settingsRow        int32
+	controlsRow        int32
+	controlsSlot       int
+	controlsListening  bool // waiting for a key or button to bind
+	controlsMessage    string
+	lastMenuMove       rl.Vector2
+	boardPage          int32
+	pendingScore       PendingScore
+	initials           [3]byte
+	initialsCursor     int32
+	afterInitials      int32 // menu item to carry out once initials are entered, -1 for none
+	menuOpen           bool
+	quit               bool
+	finished           bool
+	musicVolume        float32
+	musicMenuVolume    float32
+	camera             Camera
+	healthBar          HealthBar
+	input              Input
+	broadphase         Broadphase
+	slideAudio         SlideAudio
+	entitys            EntityPool
+	playerHandle       EntityHandle
+}
+
+func (game *Game) player() *Entity {
+	return game.entitys.get(game.playerHandle)
 }
 
 func main() {
+	resourcesFlag := flag.String("resources", "", "folder of sprites, audio and definitions to use over the built in ones (default $"+resourcesDirEnv+")")
+	dataFlag := flag.String("data", "", "directory for scores and settings (default $"+dataDirEnv+" or the user data directory)")
+	seedFlag := flag.Uint64("seed", 0, "seed for every run (default random)")
+	replayFlag := flag.String("replay", "", "replay file to play back")
+	headlessFlag := flag.Bool("headless", false, "run the simulation with no window or audio and print a summary")
+	framesFlag := flag.Int("frames", 36000, "frames to simulate with -headless")
+	inputsFlag := flag.String("inputs", "", "replay file to take input from with -headless")
+	checkAssetsFlag := flag.Bool("check-assets", false, "check every sprite, sound and font loads, with no window, and exit non-zero if any don't")
+	flag.Parse()
+	seedSet := false
+	flag.Visit(func(f *flag.Flag) {
+		seedSet = seedSet || f.Name == "seed"
+	})
+	initResourcesDir(*resourcesFlag)
+	if *checkAssetsFlag {
+		os.Exit(runAssetCheck())
+	}
+	if *headlessFlag {
+		os.Exit(runHeadless(*framesFlag, *seedFlag, seedSet, *inputsFlag))
+	}
+	initDataDir(*dataFlag)
+
 	game := Game{}
+	game.chosenSeed, game.seedChosen = *seedFlag, seedSet
 	initGame(&game)
+	if *replayFlag != "" {
+		replay, err := loadReplay(*replayFlag)
+		if err != nil {
+			rl.TraceLog(rl.LogError, "Failed to load replay %s: %v", *replayFlag, err)
+		} else {
+			startPlayback(&game, replay)
+		}
+	}
 	for !rl.WindowShouldClose() && !game.quit {
 		updateDraw(&game)
 	}
+	if game.player().hp > 0 {
+		endRun(&game)
+	}
+	submitInitials(&game)
 }
 
 const clippingPlane float32 = 10
@@ -427,9 +417,10 @@ func cameraProjectRectangle(camera Camera, input rl.Rectangle) (rl.Rectangle, bo
 	// calculate output
 	output.Width = input.Width * scale
 	output.Height = input.Height * scale
-	output.X = (xDiff*scale - output.Width/2) + float32(windowWidth)/2
+	screenWidth, screenHeight := screenSize()
+	output.X = (xDiff*scale - output.Width/2) + screenWidth/2
 	//https://www.desmos.com/calculator/lutldqk9dn
-	output.Y = float32(windowHeight) - (500 - (500*105)/(yDiff)) - output.Height
+	output.Y = screenHeight - (500 - (500*105)/(yDiff)) - output.Height
 
 	output.X -= camera.shakeX * scale * camera.shakeMagnitude
 	output.Y -= camera.shakeY * scale * camera.shakeMagnitude
@@ -448,9 +439,10 @@ func cameraProjectDot(camera Camera, y float32, dot Dot) (pos rl.Vector2, size f
 	scale := cameraFollowDistance / yDiff
 	// calculate output
 	size = 10 * scale
-	pos.X = (xDiff*scale - size/2) + float32(windowWidth)/2
+	screenWidth, screenHeight := screenSize()
+	pos.X = (xDiff*scale - size/2) + screenWidth/2
 	//https://www.desmos.com/calculator/lutldqk9dn
-	pos.Y = float32(windowHeight) - (500 - (500*105)/(yDiff)) - size - (dot.z * scale)
+	pos.Y = screenHeight - (500 - (500*105)/(yDiff)) - size - (dot.z * scale)
 
 	pos.X -= camera.shakeX * scale * camera.shakeMagnitude
 	pos.Y -= camera.shakeY * scale * camera.shakeMagnitude
@@ -458,6 +450,35 @@ func cameraProjectDot(camera Camera, y float32, dot Dot) (pos rl.Vector2, size f
 	return
 }
 
+// how far behind the camera something can still be heard
+const soundBehindDistance float32 = 500
+
+/*
+how loud something at x, y sounds from the camera and where it sits from -1 for left to 1 for right. further down
+the hill it shrinks and moves toward the middle just like in cameraProjectRectangle, so it sounds as far away as it
+looks. nothing gets louder than it would be next to the bear, and past the edge of the screen it stays panned all
+the way but still audible, so a skier off to the side is heard before it's seen. behind the camera there's no
+projection, so it fades out over soundBehindDistance instead.
+*/
+func cameraProjectSound(camera Camera, x, y float32) (volume float32, pan float32) {
+	// determine y distance relative to the camera
+	yDiff := y - camera.y
+	yDiff *= -1 // since we're looking downwards, flip the y value
+
+	scale := float32(1)
+	volume = 1
+	if yDiff > cameraFollowDistance {
+		scale = cameraFollowDistance / yDiff
+		volume = scale
+	} else if yDiff < clippingPlane {
+		volume = max(0, 1-(clippingPlane-yDiff)/soundBehindDistance)
+	}
+	xDiff := x - camera.x
+	screenWidth, _ := screenSize()
+	pan = rl.Clamp(xDiff*scale/(screenWidth/2), -1, 1)
+	return volume, pan
+}
+
 func aabbCollisionCheck(r1 rl.Rectangle, r2 rl.Rectangle) bool {
 	return r1.X+r1.Width > r2.X && r1.X < r2.X+r2.Width && r1.Y+r1.Height > r2.Y && r1.Y < r2.Y+r2.Height
 }
@@ -531,8 +552,14 @@ type AnimSource struct {
 func makeAnimSources(filenames []string) []AnimSource {
 	sources := make([]AnimSource, len(filenames))
 	for i, filename := range filenames {
-		filename = fmt.Sprint(resources.dir, "sprites/", filename)
-		texture := rl.LoadTexture(filename)
+		texture, ok := resources.textures[filename]
+		if !ok {
+			if resources.textures == nil {
+				resources.textures = map[string]rl.Texture2D{}
+			}
+			texture = loadTextureResource("sprites/" + filename)
+			resources.textures[filename] = texture
+		}
 		source := AnimSource{
 			texture:      texture,
 			width:        float32(texture.Width),
@@ -560,28 +587,39 @@ var colorDarkRed = color.RGBA{158, 40, 54, 255}
 var colorLightRed = color.RGBA{229, 59, 68, 255}
 var colorLightBlue = color.RGBA{44, 231, 244, 255}
 var colorYellow = color.RGBA{255, 231, 98, 255}
+var colorSteam = color.RGBA{226, 233, 241, 255}
 
 func draw(game Game) {
-	rl.BeginDrawing()
+	updateScreen()
+	rl.BeginTextureMode(screen.target)
 
 	/* BACKGROUND */
 	rl.ClearBackground(colorWhite)
-	drawTexture(resources.background[0].texture, rl.Rectangle{0, 0, float32(windowWidth), 400})
+	background := resources.background[0].texture
+	if game.stage >= 0 && stages[game.stage].Background != "" {
+		background = stages[game.stage].background
+	}
+	drawTexture(background, rl.Rectangle{0, 0, float32(virtualWidth), 400})
 	/* ENTITIES */
-	indices := [entitysMaxCount]indexYPair{}
-	for i := range entitysMaxCount {
-		indices[i] = indexYPair{i, game.entitys[i].y}
+	indices := make([]indexYPair, game.entitys.count())
+	for i := range game.entitys.count() {
+		indices[i] = indexYPair{i, game.entitys.at(i).y}
 
 	}
-	slices.SortFunc(indices[:], YSort)
+	slices.SortFunc(indices, YSort)
 	fogLayer := 0
 	fogLayerCount := 20
 	fogLayerDepth := float32(50)
-	for i := range entitysMaxCount {
-		entity := &game.entitys[indices[i].index]
+	ghostFrame, ghostPending := game.ghostFrame()
+	for i := range indices {
+		entity := game.entitys.at(indices[i].index)
+		if ghostPending && entity.y > ghostFrame.y {
+			drawGhost(game, ghostFrame)
+			ghostPending = false
+		}
 		if fogLayer < fogLayerCount && game.camera.y-entity.y < viewDistance-fogLayerDepth*float32(fogLayer+1) {
-			// rl.DrawRectangle(0, 250, windowWidth, windowHeight, color.RGBA{255, 0, 0, 50})
-			rl.DrawRectangle(0, 250, windowWidth, windowHeight, color.RGBA{255, 255, 255, 50})
+			// rl.DrawRectangle(0, 250, virtualWidth, virtualHeight, color.RGBA{255, 0, 0, 50})
+			rl.DrawRectangle(0, 250, virtualWidth, virtualHeight, color.RGBA{255, 255, 255, 50})
 			fogLayer += 1
 		}
 		if entity.anim.sources != nil {
@@ -630,6 +668,8 @@ func draw(game Game) {
 					color = colorBrown3
 				case dotBoost:
 					color = colorYellow
+				case dotSteam:
+					color = colorSteam
 				}
 				if color.A != 0 {
 					pos, size := cameraProjectDot(game.camera, dot.y, dot)
@@ -641,302 +681,164 @@ func draw(game Game) {
 			}
 		}
 	}
+	if ghostPending {
+		drawGhost(game, ghostFrame)
+	}
 	/* UI */
 	if game.menuOpen {
-		frameDuration := float32(2.0 / 3.0)
-		rl.GetMusicTimePlayed(resources.music)
-		// rl.DrawRectangleRec(rl.Rectangle{X: 0, Y: float32(windowHeight/2 - 4), Width: 224, Height: 182}, rl.White)
+		// rl.DrawRectangleRec(rl.Rectangle{X: 0, Y: float32(virtualHeight/2 - 4), Width: 224, Height: 182}, rl.White)
 		title := "iced birds"
 		measureTextBig(title)
-		drawTextBig(title, float32(windowWidth)/2-measureTextBig(title)/2, 120)
-
-		menuY := float32(470)
-		snowballWidth := 24
-
-		strNew := "new run"
-		strQuit := "quit game"
-		strLow := fmt.Sprintf("Lowest: %d m", int32(scores.lowest/100))
-		strQuick := fmt.Sprintf("Quickest: %d s", int32(scores.fastestTime))
-		strWin := fmt.Sprintf("Wins: %d", scores.wins)
-		width := float32(237.5 - 16)
-		width = max(width, measureText(strNew)+float32(snowballWidth+8))
-		width = max(width, measureText(strQuit)+float32(snowballWidth+8))
-		width = max(width, measureText(strLow))
-		width = max(width, measureText(strQuick))
-		width = max(width, measureText(strWin))
-		width += 16
-		var height float32
-		if scores.wins > 0 {
-			height = 150
-		} else {
-			height = 90
+		drawTextBig(title, float32(virtualWidth)/2-measureTextBig(title)/2, 120)
+
+		if game.menuPage == menuPageMain {
+			drawMainMenu(game)
 		}
-		rl.DrawRectangleRec(rl.Rectangle{X: 12, Y: menuY - 8, Width: width + 8, Height: height + 8}, rl.Black)
-		rl.DrawRectangleRec(rl.Rectangle{X: 16, Y: menuY - 4, Width: width, Height: height}, rl.White)
-
-		rl.DrawRectangleRec(rl.Rectangle{X: 20, Y: menuY, Width: width - (float32(snowballWidth) + 8) - 4, Height: 24}, colorLightGrey)
-		drawText(strNew, 20+(width-(float32(snowballWidth)+8)-4)/2-measureText(strNew)/2, menuY)
-		rl.DrawRectangleRec(rl.Rectangle{X: 20, Y: menuY + 30, Width: width - (float32(snowballWidth) + 8) - 4, Height: 24}, colorLightGrey)
-		drawText(strQuit, 20+(width-(float32(snowballWidth)+8)-4)/2-measureText(strQuit)/2, menuY+30)
-		/* CURSOR */
-		drawTextureRotating(resources.snowball[0].texture, rl.Rectangle{X: 20 + width - (float32(snowballWidth) + 4) - 4, Y: menuY + float32(game.menuSelection)*30, Width: float32(snowballWidth), Height: float32(snowballWidth)}, snowballRotationSpeed*float32(game.playTime))
-
-		/* SCORES */
-		// if scores.wins > 0 {
-		// 	rl.DrawRectangleRec(rl.Rectangle{X: float32(windowWidth - 400), Y: float32(windowHeight / 2), Width: 400, Height: 200}, rl.White)
-		// 	str := fmt.Sprintf("Lowest altitude: %d", int32(scores.lowest/100))
-		// 	width := measureText(str)
-		// 	drawText(str, float32(windowWidth)-width-24, float32(windowHeight/2))
-		// 	str = fmt.Sprintf("Fastest time: %d", int32(scores.fastestTime))
-		// 	width = measureText(str)
-		// 	drawText(str, float32(windowWidth)-width-24, float32(windowHeight/2+30))
-		// 	str = fmt.Sprintf("Fewest hits: %d", scores.fewestHits)
-		// 	width = measureText(str)
-		// 	drawText(str, float32(windowWidth)-width-24, float32(windowHeight/2+60))
-		// 	str = fmt.Sprintf("Total wins: %d", scores.wins)
-		// 	width = measureText(str)
-		// 	drawText(str, float32(windowWidth)-width-24, float32(windowHeight/2+90))
-		// }
-		if scores.wins > 0 {
-			drawText(strLow, 24, float32(menuY+60))
-			drawText(strQuick, 24, float32(menuY+90))
-			drawText(strWin, 24, float32(menuY+120))
-		} else {
-			str := fmt.Sprintf("Lowest: %d m", int32(scores.lowest/100))
-			drawText(str, 24, float32(menuY+60))
+		frame := int32(soundtrack.beat()) % 2
+		drawTexture(resources.menu[frame].texture, rl.Rectangle{0, 0, float32(virtualWidth), float32(virtualHeight)})
+		switch game.menuPage {
+		case menuPageStats:
+			drawStatsPage()
+		case menuPageBoards:
+			drawBoardsPage(game)
+		case menuPageInitials:
+			drawInitialsPage(game)
+		case menuPageSeed:
+			drawSeedPage(game)
+		case menuPageSettings:
+			drawSettingsPage(game)
+		case menuPageControls:
+			drawControlsPage(game)
 		}
-		frame := int32(rl.GetMusicTimePlayed(resources.music)/frameDuration) % 2
-		drawTexture(resources.menu[frame].texture, rl.Rectangle{0, 0, float32(windowWidth), float32(windowHeight)})
 
-	} else {
-		player := game.entitys[entitysPlayerIndex]
-		rl.DrawRectangleRec(rl.Rectangle{24, 20, 125, 75}, colorBlack)
-		rl.DrawRectangleRec(rl.Rectangle{25, 21, 123, 73}, colorWhite)
-		rl.DrawRectangleRec(rl.Rectangle{26, 22, 121, 71}, colorBlack)
-		rl.DrawRectangleRec(rl.Rectangle{27, 23, 119, 69}, colorWhite)
-
-		drawTexture(resources.icons[0].texture, rl.Rectangle{32, 30, 24, 24})
-		drawText(fmt.Sprintf("%d", int32(game.furthestY/100)), 60, 30)
-		drawTexture(resources.icons[1].texture, rl.Rectangle{32, 60, 24, 24})
-		drawText(fmt.Sprintf("%d", int32(-player.vy)/10), 60, 60)
-		timeText := fmt.Sprintf("%d", int32(game.playTime))
-		timeX := float32(windowWidth)/2 - measureText(timeText)/2 + 15
-		drawTexture(resources.icons[2].texture, rl.Rectangle{timeX - 30, 20, 24, 24})
-		drawText(timeText, timeX, 20)
-
-		health := game.healthBar.fullness
-		texture := resources.health[0].texture
-		x := game.healthBar.shakeX * game.healthBar.shakeMagnitude
-		y := game.healthBar.shakeY * game.healthBar.shakeMagnitude
-		glowSize := (1-health)*8.0 - 2
-		rl.DrawRectangleRec(rl.Rectangle{X: x + float32(windowWidth-125-24) - glowSize, Y: y + 20 - glowSize, Width: 125 + glowSize*2, Height: 75 + glowSize*2}, colorLightRed)
-		dst := rl.Rectangle{X: x + float32(windowWidth-24) - 125, Y: y + 20, Width: 125 * (1 - health), Height: 75}
-		rl.DrawTexturePro(texture, rl.Rectangle{X: 0, Y: 0, Width: float32(texture.Width) * (1 - health), Height: float32(texture.Height)}, dst, rl.Vector2{X: 0, Y: 0}, 0, rl.White)
-		texture = resources.health[1].texture
-		dst = rl.Rectangle{X: x + float32(windowWidth-24) - 125*health, Y: y + 20, Width: 125 * health, Height: 75}
-		rl.DrawTexturePro(texture, rl.Rectangle{X: float32(texture.Width) * (1 - health), Y: 0, Width: float32(texture.Width) * health, Height: float32(texture.Height)}, dst, rl.Vector2{X: 0, Y: 0}, 0, rl.White)
-		drawTexture(resources.health[2].texture, rl.Rectangle{X: x + float32(windowWidth-125-24), Y: y + 20, Width: 125, Height: 75})
-
-		// for i := range player.hp {
-		// 	drawTexture(resources.heart[0].texture, rl.Rectangle{X: float32(windowWidth - (i+1)*30), Y: 20, Width: 25, Height: 25})
-		// }
-
-		if game.notificationTimer.time > 0 {
-			textWidth := rl.MeasureTextEx(resources.font, game.notificationText, 24, 2).X
-			drawText(game.notificationText, float32(windowWidth/2)-textWidth/2, float32(windowHeight/2-24))
+	} else if showOverlay {
+		/* DEBUG OVERLAY */
+		screenWidth, screenHeight := screenSize()
+		for i := range game.entitys.count() {
+			entity := *game.entitys.at(i)
+			hitbox := entity.getHitbox()
+			hitbox.X += screenWidth/2 - game.camera.x
+			hitbox.Y += screenHeight/2 - game.camera.y
+			rl.DrawRectangleRec(hitbox, color.RGBA{0, 0, 255, 255})
 		}
+		pool := game.entitys
+		drawText(fmt.Sprintf("entitys %d peak %d of %d", pool.live, pool.peak, pool.count()), 8, screenHeight-60)
+		drawText(fmt.Sprintf("spawn failures %d", pool.spawnFailures), 8, screenHeight-32)
+	}
+	rl.EndTextureMode()
 
-		/* DEBUG OVERLAY */
-		if showOverlay {
-			for i := range entitysMaxCount {
-				entity := game.entitys[i]
-				hitbox := entity.getHitbox()
-				hitbox.X += float32(windowWidth)/2 - game.camera.x
-				hitbox.Y += float32(windowHeight)/2 - game.camera.y
-				rl.DrawRectangleRec(hitbox, color.RGBA{0, 0, 255, 255})
-			}
+	rl.BeginDrawing()
+	rl.ClearBackground(colorBlack)
+	drawScreen()
+	if !game.menuOpen {
+		beginHud()
+		drawHud(game)
+		endHud()
+	}
+	rl.EndDrawing()
+}
 
+/* the altitude and speed box, the clock and the health bar, laid out across the hud */
+func drawHud(game Game) {
+	player := *game.player()
+	box := hudPosition(anchorTopLeft, rl.Vector2{X: 125, Y: 75})
+	rl.DrawRectangleRec(rl.Rectangle{box.X, box.Y, 125, 75}, colorBlack)
+	rl.DrawRectangleRec(rl.Rectangle{box.X + 1, box.Y + 1, 123, 73}, colorWhite)
+	rl.DrawRectangleRec(rl.Rectangle{box.X + 2, box.Y + 2, 121, 71}, colorBlack)
+	rl.DrawRectangleRec(rl.Rectangle{box.X + 3, box.Y + 3, 119, 69}, colorWhite)
+
+	drawTexture(resources.icons[0].texture, rl.Rectangle{box.X + 8, box.Y + 10, 24, 24})
+	drawText(fmt.Sprintf("%d", int32(game.furthestY/100)), box.X+36, box.Y+10)
+	drawTexture(resources.icons[1].texture, rl.Rectangle{box.X + 8, box.Y + 40, 24, 24})
+	drawText(fmt.Sprintf("%d", int32(-player.vy)/10), box.X+36, box.Y+40)
+
+	// the icon hangs off the left, so the number itself sits a little right of center
+	timeText := fmt.Sprintf("%d", int32(game.playTime))
+	clock := hudPosition(anchorTop, rl.Vector2{X: measureText(timeText) + 30, Y: 24})
+	drawTexture(resources.icons[2].texture, rl.Rectangle{clock.X, clock.Y, 24, 24})
+	drawText(timeText, clock.X+30, clock.Y)
+
+	health := game.healthBar.fullness
+	texture := resources.health[0].texture
+	bar := hudPosition(anchorTopRight, rl.Vector2{X: 125, Y: 75})
+	x := bar.X + game.healthBar.shakeX*game.healthBar.shakeMagnitude
+	y := bar.Y + game.healthBar.shakeY*game.healthBar.shakeMagnitude
+	glowSize := (1-health)*8.0 - 2
+	rl.DrawRectangleRec(rl.Rectangle{X: x - glowSize, Y: y - glowSize, Width: 125 + glowSize*2, Height: 75 + glowSize*2}, colorLightRed)
+	dst := rl.Rectangle{X: x, Y: y, Width: 125 * (1 - health), Height: 75}
+	rl.DrawTexturePro(texture, rl.Rectangle{X: 0, Y: 0, Width: float32(texture.Width) * (1 - health), Height: float32(texture.Height)}, dst, rl.Vector2{X: 0, Y: 0}, 0, rl.White)
+	texture = resources.health[1].texture
+	dst = rl.Rectangle{X: x + 125*(1-health), Y: y, Width: 125 * health, Height: 75}
+	rl.DrawTexturePro(texture, rl.Rectangle{X: float32(texture.Width) * (1 - health), Y: 0, Width: float32(texture.Width) * health, Height: float32(texture.Height)}, dst, rl.Vector2{X: 0, Y: 0}, 0, rl.White)
+	drawTexture(resources.health[2].texture, rl.Rectangle{X: x, Y: y, Width: 125, Height: 75})
+
+	if delta, ok := ghostDelta(game); ok {
+		str := fmt.Sprintf("%+.1f", delta)
+		pos := hudPosition(anchorTop, rl.Vector2{X: measureText(str), Y: 24})
+		drawText(str, pos.X+15, pos.Y+28)
+	}
+	if game.replaying {
+		str := "REPLAY"
+		if game.replayMismatch {
+			str = fmt.Sprintf("REPLAY DIVERGED AT %d s", int32(game.replayMismatchTime))
 		}
+		pos := hudPosition(anchorTop, rl.Vector2{X: measureText(str), Y: 24})
+		drawText(str, pos.X, pos.Y+56)
 	}
 
-	rl.EndDrawing()
+	if game.notificationTimer.time > 0 {
+		size := rl.MeasureTextEx(resources.font, game.notificationText, 24, 2)
+		pos := hudPosition(anchorCenter, rl.Vector2{X: size.X, Y: 0})
+		drawText(game.notificationText, pos.X, pos.Y-24)
+	}
 }
 
 const walking bool = false
-const showOverlay bool = false
+
+// toggled with F3, not saved anywhere
+var showOverlay = false
 
 func updateInput(input *Input) {
 	input.move = rl.Vector2{}
-	if rl.IsKeyDown(rl.KeyUp) || rl.IsKeyDown(rl.KeyW) {
+	if controlHeld(controlUp) {
 		input.move.Y += -1.0
 	}
-	if rl.IsKeyDown(rl.KeyDown) || rl.IsKeyDown(rl.KeyS) {
+	if controlHeld(controlDown) {
 		input.move.Y += 1.0
 	}
-	if rl.IsKeyDown(rl.KeyLeft) || rl.IsKeyDown(rl.KeyA) {
+	if controlHeld(controlLeft) {
 		input.move.X += -1.0
 	}
-	if rl.IsKeyDown(rl.KeyRight) || rl.IsKeyDown(rl.KeyD) {
+	if controlHeld(controlRight) {
 		input.move.X += 1.0
 	}
-	input.move = rl.Vector2Normalize(input.move)
-	input.pause = rl.IsKeyPressed(rl.KeyEscape)
-	input.action = rl.IsKeyPressed(rl.KeySpace) || rl.IsKeyPressed(rl.KeyEnter) || rl.IsKeyPressed(rl.KeyZ) || rl.IsKeyPressed(rl.KeyX)
-	input.mute = rl.IsKeyPressed(rl.KeyM)
-}
-
-func getFirstEmptyEntity(entitys []Entity) *Entity {
-	for i := range entitys {
-		entity := &entitys[i]
-		if entity.behavior == 0 {
-			return entity
-		}
-	}
-	return nil
-}
-
-func addTree(y float32, entitys []Entity) bool {
-	slot := getFirstEmptyEntity(entitys)
-	if slot == nil {
-		return false
-	}
-	var newObstacle Entity
-	treeIndex := rl.GetRandomValue(0, int32(len(resources.trees)-1))
-	flipped := rl.GetRandomValue(0, 1) == 0
-	for {
-		x := float32(rl.GetRandomValue(-int32(hillWidth)/2, int32(hillWidth)/2))
-		yRand := float32(rl.GetRandomValue(-300, 0))
-		newObstacle = Entity{
-			x:             x,
-			y:             y + yRand,
-			width:         200,
-			height:        400,
-			hitbox:        rl.Rectangle{X: -50, Y: -25 / 2, Width: 100, Height: 25},
-			behavior:      bExists | bCanBeIced | bSolid | bExplodesOnDeath,
-			hp:            1,
-			damage:        1,
-			explosionKind: dotTree,
-			deathSound:    resources.treeBreak,
-			iceTexture:    resources.treeIce[0].texture,
-			flipped:       flipped,
-			anim:          AnimState{sources: resources.trees[:], activeIndex: treeIndex},
-		}
-		collided := false
-		for i := range entitysMaxCount {
-			entity := entitys[i]
-			box1 := rl.Rectangle{X: entity.x - 10, Y: entity.y - 25, Width: 20, Height: 50}
-			box2 := rl.Rectangle{X: newObstacle.x - 10, Y: newObstacle.y - 25, Width: 20, Height: 50}
-
-			if aabbCollisionCheck(box1, box2) {
-				collided = true
-				break
-			}
-		}
-		if !collided {
-			break
-		}
-	}
-	*slot = newObstacle
-	return true
-}
-
-func addRock(y float32, entitys []Entity) bool {
-	slot := getFirstEmptyEntity(entitys)
-	if slot == nil {
-		return false
-	}
-	var newObstacle Entity
-	rockIndex := rl.GetRandomValue(0, int32(len(resources.rock)-1))
-	flipped := rl.GetRandomValue(0, 1) == 0
-	for {
-		x := float32(rl.GetRandomValue(-int32(hillWidth)/2, int32(hillWidth)/2))
-		yRand := float32(rl.GetRandomValue(-300, 0))
-		newObstacle = Entity{
-			x:             x,
-			y:             y + yRand,
-			width:         200,
-			height:        200,
-			hitbox:        rl.Rectangle{X: -80, Y: -25 / 2, Width: 160, Height: 25},
-			behavior:      bExists | bSolid | bExplodesOnDeath,
-			hp:            1,
-			damage:        1,
-			explosionKind: dotRock,
-			deathSound:    resources.rockBreak,
-			flipped:       flipped,
-			anim:          AnimState{sources: resources.rock[:], activeIndex: rockIndex},
-		}
-		collided := false
-		for i := range entitysMaxCount {
-			entity := entitys[i]
-			box1 := rl.Rectangle{X: entity.x - 10, Y: entity.y - 25, Width: 20, Height: 50}
-			box2 := rl.Rectangle{X: newObstacle.x - 10, Y: newObstacle.y - 25, Width: 20, Height: 50}
-
-			if aabbCollisionCheck(box1, box2) {
-				collided = true
-				break
-			}
-		}
-		if !collided {
-			break
-		}
-	}
-	*slot = newObstacle
-	return true
-}
-
-func addTrap(y float32, entitys []Entity) bool {
-	slot := getFirstEmptyEntity(entitys)
-	if slot == nil {
-		return false
-	}
-	var newObstacle Entity
-	flipped := rl.GetRandomValue(0, 1) == 0
-	for {
-		x := float32(rl.GetRandomValue(-int32(hillWidth)/2, int32(hillWidth)/2))
-		yRand := float32(rl.GetRandomValue(-300, 0))
-		newObstacle = Entity{
-			x:        x,
-			y:        y + yRand,
-			width:    200,
-			height:   150,
-			hitbox:   rl.Rectangle{X: -80, Y: -25 / 2, Width: 160, Height: 25},
-			behavior: bExists | bLow | bInvincible,
-			hp:       100,
-			damage:   100,
-			flipped:  flipped,
-			anim:     AnimState{sources: resources.trap[:], activeIndex: trapOpenAnimIndex},
-		}
-		collided := false
-		for i := range entitysMaxCount {
-			entity := entitys[i]
-			box1 := rl.Rectangle{X: entity.x - 10, Y: entity.y - 25, Width: 20, Height: 50}
-			box2 := rl.Rectangle{X: newObstacle.x - 10, Y: newObstacle.y - 25, Width: 20, Height: 50}
-
-			if aabbCollisionCheck(box1, box2) {
-				collided = true
-				break
-			}
-		}
-		if !collided {
-			break
-		}
+	// left unnormalized so a diagonal still steers at full speed
+	input.pause = controlPressed(controlPause)
+	input.action = controlPressed(controlAction)
+	input.mute = controlPressed(controlMute)
+	input.char = rune(rl.GetCharPressed())
+	input.erase = rl.IsKeyPressed(rl.KeyBackspace)
+	altHeld := rl.IsKeyDown(rl.KeyLeftAlt) || rl.IsKeyDown(rl.KeyRightAlt)
+	input.fullscreen = rl.IsKeyPressed(rl.KeyF11) || (altHeld && rl.IsKeyPressed(rl.KeyEnter))
+	if input.fullscreen {
+		// so alt enter doesn't also pick whatever menu item is selected
+		input.action = false
 	}
-	*slot = newObstacle
-	return true
+	input.overlay = rl.IsKeyPressed(rl.KeyF3)
+	input.padLost = false
+	updateGamepadInput(input)
 }
 
-func addOuterTree(y float32, entitys []Entity) bool {
-	var x float32
-	for {
-		x = float32(rl.GetRandomValue(-int32(hillWidth), int32(hillWidth)))
-		if !(x >= -float32(hillWidth)/2-50 && x <= float32(hillWidth)/2+50) {
-			break
-		}
+func addOuterTree(y float32, entitys *EntityPool, rng *Rng) bool {
+	// somewhere off either side of the hill, clear of the poles
+	x := float32(rng.value(int32(hillWidth)/2+51, int32(hillWidth)))
+	if rng.value(0, 1) == 0 {
+		x = -x
 	}
-	y += float32(rl.GetRandomValue(-300, 0))
-	treeIndex := rl.GetRandomValue(0, int32(len(resources.trees)-1))
-	flipped := rl.GetRandomValue(0, 1) == 0
-	slot := getFirstEmptyEntity(entitys)
+	y += float32(rng.value(-300, 0))
+	treeIndex := rng.value(0, int32(len(resources.trees)-1))
+	flipped := rng.value(0, 1) == 0
+	slot, _ := entitys.alloc()
 	if slot != nil {
 		*slot = Entity{
 			x:        x,
@@ -944,6 +846,7 @@ func addOuterTree(y float32, entitys []Entity) bool {
 			width:    400,
 			height:   800,
 			behavior: bExists | bInvincible,
+			kind:     kindOuterTree,
 			hp:       100,
 			damage:   0,
 			flipped:  flipped,
@@ -954,56 +857,14 @@ func addOuterTree(y float32, entitys []Entity) bool {
 	return false
 }
 
-func addCrap(y float32, entitys []Entity) bool {
-	slot := getFirstEmptyEntity(entitys)
-	if slot == nil {
-		return false
-	}
-	var newObstacle Entity
-	crapIndex := rl.GetRandomValue(0, int32(len(resources.crap)-1))
-	flipped := rl.GetRandomValue(0, 1) == 0
-	for {
-		x := float32(rl.GetRandomValue(-int32(hillWidth), int32(hillWidth)))
-		yRand := float32(rl.GetRandomValue(-300, 0))
-		newObstacle = Entity{
-			x: x,
-			y: y + yRand,
-			// width:    150,
-			// height:   20,
-			width:    100,
-			height:   50,
-			behavior: bExists | bLow | bInvincible,
-			hp:       100,
-			damage:   0,
-			flipped:  flipped,
-			anim:     AnimState{sources: resources.crap[:], activeIndex: crapIndex},
-		}
-		collided := false
-		for i := range entitysMaxCount {
-			entity := entitys[i]
-			box1 := rl.Rectangle{X: entity.x - 10, Y: entity.y - 25, Width: 20, Height: 50}
-			box2 := rl.Rectangle{X: newObstacle.x - 10, Y: newObstacle.y - 25, Width: 20, Height: 50}
-
-			if aabbCollisionCheck(box1, box2) {
-				collided = true
-				break
-			}
-		}
-		if !collided {
-			break
-		}
-	}
-	*slot = newObstacle
-	return true
-}
-func addSkier(y float32, entitys []Entity) bool {
-	x := float32(rl.GetRandomValue(-int32(hillWidth)/2+300, int32(hillWidth)/2-300))
+func addSkier(y float32, entitys *EntityPool, rng *Rng) bool {
+	x := float32(rng.value(-int32(hillWidth)/2+300, int32(hillWidth)/2-300))
 	vx := float32(800)
-	goLeft := rl.GetRandomValue(0, 1) == 1
+	goLeft := rng.value(0, 1) == 1
 	if goLeft {
 		vx *= -1
 	}
-	slot := getFirstEmptyEntity(entitys)
+	slot, _ := entitys.alloc()
 	if slot != nil {
 		*slot = Entity{
 			x:             x,
@@ -1024,6 +885,7 @@ func addSkier(y float32, entitys []Entity) bool {
 			iceTexture:    resources.penguinIce[0].texture,
 			anim:          AnimState{sources: resources.penguin[:]},
 			behavior:      bExists | bSkier | bCanBeIced | bDropsItem | bExplodesOnDeath,
+			kind:          kindSkier,
 		}
 		return true
 	}
@@ -1033,10 +895,10 @@ func addSkier(y float32, entitys []Entity) bool {
 const snowballSpeed float32 = 1000
 const snowballRotationSpeed float32 = 600
 
-func addSnowball(x float32, y float32, vy float32, entitys []Entity) bool {
-	slot := getFirstEmptyEntity(entitys)
+func addSnowball(x float32, y float32, vy float32, entitys *EntityPool, rng *Rng) bool {
+	slot, _ := entitys.alloc()
 	if slot != nil {
-		ballIndex := rl.GetRandomValue(0, int32(len(resources.snowball)-1))
+		ballIndex := rng.value(0, int32(len(resources.snowball)-1))
 		*slot = Entity{
 			x:             x,
 			y:             y,
@@ -1050,49 +912,50 @@ func addSnowball(x float32, y float32, vy float32, entitys []Entity) bool {
 			deathSound:    resources.snowballImpact,
 			anim:          AnimState{sources: resources.snowball[:], activeIndex: ballIndex},
 			behavior:      bExists | bDynamic | bSolid | bCausesIce | bExplodesOnDeath | bHigh,
+			kind:          kindSnowball,
 		}
 		return true
 	}
 	return false
 }
 
-func addBarriers(y float32, entitys []Entity) {
-	leftDone := false
-	for i := range entitys {
-		entity := &entitys[i]
-		if entity.behavior == 0 {
-			if !leftDone {
-				*entity = Entity{
-					x:        -hillWidth / 2,
-					y:        y,
-					hp:       100, // TODO get rid of this once i fix the transparency debug thing
-					width:    20,
-					height:   100,
-					behavior: bExists | bInvincible,
-					anim:     AnimState{sources: resources.pole[:]},
-				}
-				leftDone = true
-			} else {
-				*entity = Entity{
-					x:        hillWidth / 2,
-					y:        y,
-					hp:       100, // TODO get rid of this once i fix the transparency debug thing
-					width:    20,
-					height:   100,
-					behavior: bExists,
-					anim:     AnimState{sources: resources.pole[:]},
-				}
-				break
-			}
-		}
+func addBarriers(y float32, entitys *EntityPool) {
+	left, _ := entitys.alloc()
+	if left == nil {
+		return
+	}
+	*left = Entity{
+		x:        -hillWidth / 2,
+		y:        y,
+		hp:       100, // TODO get rid of this once i fix the transparency debug thing
+		width:    20,
+		height:   100,
+		behavior: bExists | bInvincible,
+		kind:     kindPole,
+		anim:     AnimState{sources: resources.pole[:]},
+	}
+	right, _ := entitys.alloc()
+	if right == nil {
+		return
+	}
+	*right = Entity{
+		x:        hillWidth / 2,
+		y:        y,
+		hp:       100, // TODO get rid of this once i fix the transparency debug thing
+		width:    20,
+		height:   100,
+		behavior: bExists,
+		kind:     kindPole,
+		anim:     AnimState{sources: resources.pole[:]},
 	}
 }
 
 const boostTime float32 = 5
 const boostSpeed float32 = 3000
 
-func addPlayer(entitys []Entity) {
-	entitys[entitysPlayerIndex] = Entity{
+func addPlayer(entitys *EntityPool) EntityHandle {
+	slot, handle := entitys.alloc()
+	*slot = Entity{
 		vy:            -300,
 		y:             startingHeight,
 		width:         100,
@@ -1111,7 +974,9 @@ func addPlayer(entitys []Entity) {
 		anim:          AnimState{sources: resources.bear[:]},
 		explosionKind: dotBlood,
 		behavior:      bExists | bEarnsPoints | bDynamic | bSolid | bExplodesOnDeath,
+		kind:          kindPlayer,
 	}
+	return handle
 }
 
 func createEmpty() Entity {
@@ -1149,25 +1014,36 @@ func (entity Entity) hasBehavior(flags uint64) bool {
 	return (^entity.behavior & flags) == 0
 }
 
-func tryIce(e1 *Entity, e2 *Entity) {
+func tryIce(e1 *Entity, e2 *Entity, stats *RunStats) {
 	if e1.hasBehavior(bCausesIce) && e2.hasBehavior(bCanBeIced) {
+		if e2.hasBehavior(bSkier) && !e2.hasBehavior(bIced) {
+			stats.frozen += 1
+		}
 		e2.behavior |= bIced
 		e1.addDamage(e2.damage)
-		rl.PlaySound(resources.iced)
+		backend.playSoundAt(resources.iced, e2.x, e2.y)
 		//*e1 = createEmpty()
 
 	}
 
 }
 
-func tryDamage(e1 *Entity, e2 *Entity) bool {
+func tryDamage(e1 *Entity, e2 *Entity, stats *RunStats) bool {
 	miss := (e1.hasBehavior(bHigh) && e2.hasBehavior(bLow)) || (e1.hasBehavior(bLow) && e2.hasBehavior(bHigh))
 	if e1.invulnTimer.time <= 0 && e2.damage > 0 && !e2.hasBehavior(bIced) && !miss {
 		e1.addDamage(e2.damage)
 		e1.wishSpeed *= 0.75
-		if &e2.anim.sources[0] == &resources.trap[0] {
-			rl.PlaySound(resources.trapClosing)
-			e2.anim.activeIndex = trapClosedAnimIndex
+		if e1.kind == kindPlayer {
+			stats.hits += 1
+			if e1.hp <= 0 {
+				stats.cause = uint8(e2.kind)
+			}
+		}
+		if e2.archetype != nil && e2.archetype.HitSound != "" {
+			backend.playSoundAt(e2.archetype.hitSound, e2.x, e2.y)
+		}
+		if e2.archetype != nil && e2.archetype.HitSprite != nil {
+			e2.anim.activeIndex = *e2.archetype.HitSprite
 		}
 		return true
 	}
@@ -1175,7 +1051,7 @@ func tryDamage(e1 *Entity, e2 *Entity) bool {
 }
 
 /* returns which item was given */
-func (entity *Entity) giveRandomItem() int32 {
+func (entity *Entity) giveRandomItem(stats *RunStats) int32 {
 	var item int32
 	if entity.hp < entity.hpMax {
 		item = itemHealth
@@ -1192,31 +1068,35 @@ func (entity *Entity) giveRandomItem() int32 {
 		entity.boostTimer.reset()
 		entity.behavior |= bSmashEverything
 		entity.smashTimer.reset()
-		rl.StopSound(resources.scoop)
+		backend.stopSound(resources.scoop)
 		entity.attackTimer.time = 0
 		entity.anim.activeIndex = centerAnimIndex
 	}
-	rl.PlaySound(resources.item)
+	backend.playSound(resources.item)
+	stats.items += 1
 	return item
 }
 
-func tryDeath(entity *Entity, vy float32, now float64) {
+func tryDeath(entity *Entity, vy float32, now float64, stats *RunStats, rng *Rng) {
 	if entity.hp <= 0 {
+		if entity.archetype != nil && entity.archetype.CountsAsSmashed {
+			stats.smashed += 1
+		}
 		if entity.hasBehavior(bIced) {
-			rl.PlaySound(resources.iceBreak)
+			backend.playSoundAt(resources.iceBreak, entity.x, entity.y)
 		}
-		rl.PlaySound(entity.deathSound)
+		backend.playSoundAt(entity.deathSound, entity.x, entity.y)
 		if entity.hasBehavior(bExplodesOnDeath) {
 			entity.vx = 0
 			entity.vy = 0
-			entity.explode(vy, now)
+			entity.explode(vy, now, rng)
 			entity.anim.sources = nil
 			entity.behavior = bExplosion
 		}
 	}
 }
 
-func (entity *Entity) explode(vy float32, now float64) {
+func (entity *Entity) explode(vy float32, now float64, rng *Rng) {
 	entity.dots = make([]Dot, 30)
 	for i := range entity.dots {
 		dot := &entity.dots[i]
@@ -1228,9 +1108,9 @@ func (entity *Entity) explode(vy float32, now float64) {
 			x:      entity.x,
 			y:      entity.y,
 			z:      entity.height / 2,
-			vx:     float32(rl.GetRandomValue(-800, 800)),
+			vx:     float32(rng.value(-800, 800)),
 			vy:     vy,
-			vz:     float32(rl.GetRandomValue(-800, 800)),
+			vz:     float32(rng.value(-800, 800)),
 			expiry: now + 1,
 			kind:   kind,
 		}
@@ -1266,17 +1146,25 @@ func update(game *Game) {
 	// we always hit 60fps, actually getting frame time only causes crazy stuff to happen on stalls
 	// frameTime := rl.GetFrameTime()
 	frameTime := float32(1.0 / 60.0)
-	player := &game.entitys[entitysPlayerIndex]
+	player := game.player()
 	playerMomentum := player.vy
 
-	rl.UpdateMusicStream(resources.music)
-	rl.UpdateMusicStream(resources.musicMenu)
-	rl.UpdateMusicStream(resources.slideCenter)
-	rl.UpdateMusicStream(resources.slideSide)
+	backend.updateMusic(resources.slideCenter)
+	backend.updateMusic(resources.slideSide)
+	backend.updateMusic(resources.slideBoost)
 
-	updateInput(&game.input)
+	backend.setListener(game.camera)
+	backend.pollInput(&game.input)
+	game.input.quantize()
+	if game.controlsListening {
+		game.input = Input{} // the next key is for the controls page and nothing else
+	}
 
-	if game.input.pause && (player.hp > 0 || game.deathTimer.time > 0) {
+	if game.input.padLost && !game.menuOpen {
+		game.input.pause = true // pause if the controller gets pulled out mid-run
+	}
+	subPageOpen := game.menuOpen && game.menuPage != menuPageMain // pages handle escape themselves
+	if game.input.pause && !subPageOpen && (player.hp > 0 || game.deathTimer.time > 0) {
 		game.menuOpen = !game.menuOpen
 		if game.menuOpen {
 			pauseSounds()
@@ -1289,14 +1177,21 @@ func update(game *Game) {
 		pauseSounds()
 	}
 	if game.input.mute {
-		if game.muted {
-			game.muted = false
-		} else {
-			game.muted = true
-		}
+		toggleMute()
+	}
+	if game.input.fullscreen {
+		toggleFullscreen()
+	}
+	if game.input.overlay {
+		showOverlay = !showOverlay
+	}
+
+	simulating := !(game.menuOpen && player.hp > 0)
+	if simulating {
+		stepReplay(game)
 	}
 
-	if !(game.menuOpen && player.hp > 0) {
+	if simulating {
 		game.playTime += float64(frameTime)
 		// game.musicVolume = min(1, game.musicVolume+frameTime)
 		// game.musicMenuVolume = max(0, game.musicMenuVolume-frameTime)
@@ -1305,7 +1200,7 @@ func update(game *Game) {
 		wasScooping := player.attackTimer.time > 0
 		player.attackTimer.time -= frameTime
 		if wasScooping && player.attackTimer.time <= 0 {
-			rl.PlaySound(resources.snowballReady)
+			backend.playSound(resources.snowballReady)
 		}
 		wasBoosting := player.boostTimer.time > 0
 		player.boostTimer.time -= frameTime
@@ -1313,7 +1208,7 @@ func update(game *Game) {
 			player.invulnTimer.reset()
 			game.skierTimer.reset()
 			player.behavior &^= bInvincible
-			rl.StopSound(resources.boost)
+			backend.stopSound(resources.boost)
 		}
 		wasSmashing := player.smashTimer.time > 0
 		player.smashTimer.time -= frameTime
@@ -1331,9 +1226,9 @@ func update(game *Game) {
 		if player.hp > 0 {
 			if player.boostTimer.time <= 0 {
 				if game.input.action && player.attackTimer.time <= 0 {
-					if addSnowball(player.x, player.y-50, player.vy-snowballSpeed, game.entitys[:]) {
+					if addSnowball(player.x, player.y-50, player.vy-snowballSpeed, &game.entitys, &game.fxRng) {
 						player.attackTimer.reset()
-						rl.PlaySound(resources.snowballThrow)
+						backend.playSound(resources.snowballThrow)
 					}
 				}
 				if game.input.move.X > 0 {
@@ -1344,7 +1239,7 @@ func update(game *Game) {
 					} else {
 						player.anim.activeIndex = rightAnimIndex
 					}
-					player.vx = player.wishSpeed * 2
+					player.vx = player.wishSpeed * 2 * game.input.move.X
 				} else if game.input.move.X < 0 {
 					if player.attackTimer.time > player.attackTimer.max*0.8 {
 						player.anim.activeIndex = leftThrowAnimIndex
@@ -1353,7 +1248,7 @@ func update(game *Game) {
 					} else {
 						player.anim.activeIndex = leftAnimIndex
 					}
-					player.vx = -player.wishSpeed * 2
+					player.vx = player.wishSpeed * 2 * game.input.move.X
 				} else {
 					if player.attackTimer.time > player.attackTimer.max*0.8 {
 						player.anim.activeIndex = centerThrowAnimIndex
@@ -1364,28 +1259,16 @@ func update(game *Game) {
 					}
 					player.vx = 0
 				}
-				if player.vx == 0 {
-					if !rl.IsMusicStreamPlaying(resources.slideCenter) {
-
-						rl.PlayMusicStream(resources.slideCenter)
-					}
-					rl.StopMusicStream(resources.slideSide)
-				} else {
-					if !rl.IsMusicStreamPlaying(resources.slideSide) {
-						rl.PlayMusicStream(resources.slideSide)
-					}
-					rl.StopMusicStream(resources.slideCenter)
-				}
 				if player.vy > 0 {
 					player.anim.activeIndex = hurtAnimIndex
 				}
-				if rl.GetRandomValue(0, 700) < int32(abs(player.vy)) {
+				if game.fxRng.value(0, 700) < int32(abs(player.vy)) {
 					if player.snowTimer.time <= 0 {
-						x := float32(rl.GetRandomValue(-10, 10))
-						y := float32(rl.GetRandomValue(0, -30))
-						z := float32(rl.GetRandomValue(0, 0))
-						vx := float32(rl.GetRandomValue(-100, 100))
-						vz := float32(rl.GetRandomValue(-100, 100))
+						x := float32(game.fxRng.value(-10, 10))
+						y := float32(game.fxRng.value(0, -30))
+						z := float32(game.fxRng.value(0, 0))
+						vx := float32(game.fxRng.value(-100, 100))
+						vz := float32(game.fxRng.value(-100, 100))
 
 						player.addTrail(player.x+x, player.y+y, z, vx, 0, 300+vz, game.playTime, game.playTime+.5, 1, dotSnow)
 						player.snowTimer.reset()
@@ -1398,12 +1281,12 @@ func update(game *Game) {
 				/* SOUND */
 				if player.attackTimer.time > player.attackTimer.max*0.8 {
 				} else if player.attackTimer.time > 0 {
-					if !rl.IsSoundPlaying(resources.scoop) {
-						rl.PlaySound(resources.scoop)
+					if !backend.isSoundPlaying(resources.scoop) {
+						backend.playSound(resources.scoop)
 					}
 				} else {
-					if rl.IsSoundPlaying(resources.scoop) {
-						rl.StopSound(resources.scoop)
+					if backend.isSoundPlaying(resources.scoop) {
+						backend.stopSound(resources.scoop)
 					}
 				}
 
@@ -1433,10 +1316,10 @@ func update(game *Game) {
 			} else {
 				if player.snowTimer.time <= 0 {
 					for range 10 {
-						x := float32(rl.GetRandomValue(-int32(player.width/2), int32(player.width/2)))
-						y := float32(rl.GetRandomValue(0, -30))
-						z := float32(rl.GetRandomValue(int32(player.height*0.2), int32(player.height*0.8)))
-						vx := float32(rl.GetRandomValue(-100, 100))
+						x := float32(game.fxRng.value(-int32(player.width/2), int32(player.width/2)))
+						y := float32(game.fxRng.value(0, -30))
+						z := float32(game.fxRng.value(int32(player.height*0.2), int32(player.height*0.8)))
+						vx := float32(game.fxRng.value(-100, 100))
 						// vy := float32(rl.GetRandomValue(-100, 100))
 
 						player.addTrail(player.x+x, player.y+y, z, vx, -2000, 0, game.playTime, game.playTime+.5, 1, dotBoost)
@@ -1444,75 +1327,20 @@ func update(game *Game) {
 					player.snowTimer.reset()
 				}
 			}
+			updateSlideAudio(game, frameTime)
 		}
 
 		/* BARRIERS */
 		if game.camera.y-viewDistance <= game.lastBarrierY-barrierDistance {
-			addBarriers(game.lastBarrierY-barrierDistance, game.entitys[:])
+			addBarriers(game.lastBarrierY-barrierDistance, &game.entitys)
 			game.lastBarrierY -= barrierDistance
 		}
 
 		/* SPAWNING */
-		treeDifficulty := float32(0)
-		rockDifficulty := float32(0)
-		trapDifficulty := float32(0)
-		levelDistance := startingHeight / 3
-		if player.y > levelDistance*2 {
-			treeDifficulty = (levelDistance*3 - player.y) / levelDistance
-			rockDifficulty = 0
-			trapDifficulty = 0
-		} else if player.y > levelDistance {
-			rockDifficulty = (levelDistance*2 - player.y) / (levelDistance * 2)
-			treeDifficulty = 1 - rockDifficulty
-			trapDifficulty = 0
-		} else if player.y > 0 {
-			rockDifficulty = (levelDistance - player.y) / (levelDistance * 3)
-			trapDifficulty = (levelDistance - player.y) / (levelDistance * 3)
-			treeDifficulty = 1 - rockDifficulty - trapDifficulty
-		} else {
-			treeDifficulty = 0
-			rockDifficulty = 0
-			trapDifficulty = 1
-		}
-		if treeDifficulty > 0 {
-			treeCost := 50 / treeDifficulty
-			for game.treePoints > treeCost {
-				if addTree(game.camera.y-viewDistance, game.entitys[:]) {
-					game.treePoints -= treeCost
-				} else {
-					break
-				}
-			}
-		}
-		if rockDifficulty > 0 {
-			rockCost := 50 / rockDifficulty
-			for game.rockPoints > rockCost {
-				if addRock(game.camera.y-viewDistance, game.entitys[:]) {
-					game.rockPoints -= rockCost
-				} else {
-					break
-				}
-			}
-		}
-		if trapDifficulty > 0 {
-			trapCost := 100 / trapDifficulty
-			for game.trapPoints > trapCost {
-				if addTrap(game.camera.y-viewDistance, game.entitys[:]) {
-					game.trapPoints -= trapCost
-				} else {
-					break
-				}
-			}
-		}
-		for game.crapPoints > 50 {
-			if addCrap(game.camera.y-viewDistance, game.entitys[:]) {
-				game.crapPoints -= 50
-			} else {
-				break
-			}
-		}
+		updateStage(game)
+		directSpawns(game)
 		for game.outerTreePoints > 25 {
-			if addOuterTree(game.camera.y-viewDistance, game.entitys[:]) {
+			if addOuterTree(game.camera.y-viewDistance, &game.entitys, &game.rng) {
 				game.outerTreePoints -= 25
 			} else {
 				break
@@ -1520,20 +1348,24 @@ func update(game *Game) {
 		}
 
 		skierCount := 0
-		for i := range entitysMaxCount {
-			entity := &game.entitys[i]
+		for i := range game.entitys.count() {
+			entity := game.entitys.at(i)
 			if entity.hasBehavior(bSkier) {
 				skierCount += 1
 			}
 		}
-		if game.skierTimer.time <= 0 && skierCount < 2 && player.boostTimer.time <= 0 {
-			addSkier(game.camera.y-viewDistance, game.entitys[:])
+		maxSkiers := 0
+		if game.stage >= 0 {
+			maxSkiers = stages[game.stage].MaxSkiers
+		}
+		if game.skierTimer.time <= 0 && skierCount < maxSkiers && player.boostTimer.time <= 0 {
+			addSkier(game.camera.y-viewDistance, &game.entitys, &game.rng)
 			game.skierTimer.reset()
 		}
 
 		/* BASIC LOOP */
-		for i := range entitysMaxCount {
-			entity := &game.entitys[i]
+		for i := range game.entitys.count() {
+			entity := game.entitys.at(i)
 
 			/* MOVE */
 			if !entity.hasBehavior(bIced) {
@@ -1561,7 +1393,7 @@ func update(game *Game) {
 					}
 					if entity.y == player.y-50 && abs(player.x-entity.x) < 200 {
 						entity.wishSpeed = player.wishSpeed + 400
-						rl.PlaySound(resources.penguinSquawk)
+						backend.playSoundAt(resources.penguinSquawk, entity.x, entity.y)
 						entity.shockedTimer.reset()
 					} else {
 						entity.wishSpeed -= 25 * frameTime
@@ -1572,11 +1404,11 @@ func update(game *Game) {
 						entity.anim.activeIndex = shockedAnimIndex
 					}
 					if entity.snowTimer.time <= 0 {
-						x := float32(rl.GetRandomValue(-10, 10))
-						y := float32(rl.GetRandomValue(0, -30))
-						z := float32(rl.GetRandomValue(0, 0))
-						vx := float32(rl.GetRandomValue(-100, 100))
-						vz := float32(rl.GetRandomValue(-100, 100))
+						x := float32(game.fxRng.value(-10, 10))
+						y := float32(game.fxRng.value(0, -30))
+						z := float32(game.fxRng.value(0, 0))
+						vx := float32(game.fxRng.value(-100, 100))
+						vz := float32(game.fxRng.value(-100, 100))
 
 						entity.addTrail(entity.x+x, entity.y+y, z, vx, 0, 300+vz, game.playTime, game.playTime+2, 1, dotSnow)
 						entity.snowTimer.reset()
@@ -1593,7 +1425,9 @@ func update(game *Game) {
 					*dot = createEmptyDot()
 				} else {
 					dotsLiving = true
-					dot.vz -= 1000 * frameTime // gravity
+					if dot.kind != dotSteam {
+						dot.vz -= 1000 * frameTime // gravity
+					}
 					dot.x += dot.vx * frameTime
 					dot.y += dot.vy * frameTime
 					dot.z += dot.vz * frameTime
@@ -1605,14 +1439,14 @@ func update(game *Game) {
 			}
 
 			/* DESPAWN */
-			if entity != player {
+			if entity != player && entity.behavior != 0 {
 				if entity.hasBehavior(bExplosion) {
 					if !dotsLiving {
-						*entity = createEmpty()
+						game.entitys.release(i)
 					}
 				} else {
 					if entity.y > game.camera.y+50 || entity.y < game.camera.y-3000 {
-						*entity = createEmpty()
+						game.entitys.release(i)
 					}
 				}
 			}
@@ -1625,125 +1459,15 @@ func update(game *Game) {
 		}
 
 		/* COLLISIONS */
-		for i1 := range entitysMaxCount {
-			e1 := &game.entitys[i1]
-			if !e1.hasBehavior(bDynamic|bSolid) || e1.hp <= 0 {
-				continue
-			}
-			for i2 := range entitysMaxCount {
-				if i1 == i2 {
-					continue
-				}
-				e2 := &game.entitys[i2]
-				if e2.hp <= 0 {
-					continue
-				}
-				collision := aabbCollision(e1.getHitbox(), e2.getHitbox())
-				if collision.Width != 0 && collision.Height != 0 {
-					if e1 == player && e2.hasBehavior(bSolid) {
-						game.camera.shakeMagnitude += 30
-					}
-					tryIce(e1, e2)
-					tryIce(e2, e1)
-
-					if !e1.hasBehavior(bSmashEverything) && e2.hasBehavior(bSolid) && !e2.hasBehavior(bIced) {
-						displacement1 := -e1.vy / abs(e1.vy-e2.vy) * collision.Height
-						e1.y += displacement1
-						displacement2 := -e2.vy / abs(e2.vy-e1.vy) * collision.Height
-						e2.y += displacement2
-						e1.vy = rl.Clamp(-e1.vy, -100, 100)
-						e2.vy = rl.Clamp(-e2.vy, -100, 100)
-						if math.IsNaN(float64(e1.y)) || math.IsNaN(float64(e1.x)) {
-							panic("NaN position")
-						}
-
-					}
-
-					if !e1.hasBehavior(bInvincible) {
-						damaged := tryDamage(e1, e2)
-						if e1 == player && damaged {
-							game.hits += 1
-							game.healthBar.shakeMagnitude += 50
-							rl.PlaySound(resources.impact)
-						}
-					}
-					if !e2.hasBehavior(bInvincible) {
-						damaged := tryDamage(e2, e1)
-						if e2 == player && damaged {
-							game.hits += 1
-							game.healthBar.shakeMagnitude += 50
-							rl.PlaySound(resources.impact)
-						}
-					}
-					if (e1 == player || e2 == player) && player.hp <= 0 {
-						scores.lowest = min(scores.lowest, player.y)
-						rl.StopSound(resources.scoop)
-						rl.StopMusicStream(resources.slideCenter)
-						rl.StopMusicStream(resources.slideSide)
-						saveScores()
-						game.deathTimer.reset()
-					}
-					if (e1.hasBehavior(bDropsItem) && e1.hp <= 0) || (e2.hasBehavior(bDropsItem) && e2.hp <= 0) {
-						item := player.giveRandomItem()
-						switch item {
-						case itemHealth:
-							game.healthBar.shakeMagnitude += 50
-							which := rl.GetRandomValue(0, 5)
-							var txt string
-							switch which {
-							case 0:
-								txt = "DELICIOUS!"
-							case 1:
-								txt = "DELECTABLE!"
-							case 2:
-								txt = "SCRUMPTIOUS!"
-							case 3:
-								txt = "YUMMY!"
-							case 4:
-								txt = "MMMM!"
-							case 5:
-								txt = "TASTY!"
-							}
-							game.notificationText = txt
-						case itemBoost:
-							rl.PlaySound(resources.boost)
-							game.notificationText = "BOOST!"
-						}
-						game.notificationTimer.reset()
-						game.skierTimer.reset()
-					}
-					var vy float32
-					if e2 == player {
-						vy = playerMomentum
-					} else {
-						vy = 0
-					}
-					tryDeath(e1, vy, game.playTime)
-					if e1 == player {
-						vy = playerMomentum
-
-					} else {
-						vy = 0
-					}
-					tryDeath(e2, vy, game.playTime)
-					if !e1.hasBehavior(bDynamic|bSolid) || e1.hp <= 0 {
-						break
-					}
-				}
-			}
-		}
+		resolveCollisions(game, playerMomentum)
 
+		/* MELTING */
+		meltOnLava(game)
 	} else {
 		game.musicMenuVolume = min(1, 1-(1-game.musicMenuVolume)*0.9)
 		game.musicVolume = max(0, game.musicVolume*0.9)
 	}
-	if game.muted {
-		rl.SetMusicVolume(resources.music, 0)
-		rl.SetMusicVolume(resources.musicMenu, 0)
-	} else {
-		rl.SetMusicVolume(resources.musicMenu, game.musicMenuVolume)
-		rl.SetMusicVolume(resources.music, game.musicVolume)
-	}
+	soundtrack.update(game, frameTime)
 	{
 		health := float32(max(0, player.hp)) / float32(player.hpMax)
 		speed := float32(3)
@@ -1762,10 +1486,10 @@ func update(game *Game) {
 		game.camera.track(0, game.camera.y-cameraScrollSpeed*frameTime, 5, frameTime)
 	}
 	if int32(game.playTime*200)%2 == 0 {
-		game.camera.shakeX = float32(rl.GetRandomValue(-int32(50), int32(50))) / 100
-		game.camera.shakeY = float32(rl.GetRandomValue(-int32(50), int32(50))) / 100
-		game.healthBar.shakeX = float32(rl.GetRandomValue(-int32(50), int32(50))) / 100
-		game.healthBar.shakeY = float32(rl.GetRandomValue(-int32(50), int32(50))) / 100
+		game.camera.shakeX = float32(game.fxRng.value(-int32(50), int32(50))) / 100
+		game.camera.shakeY = float32(game.fxRng.value(-int32(50), int32(50))) / 100
+		game.healthBar.shakeX = float32(game.fxRng.value(-int32(50), int32(50))) / 100
+		game.healthBar.shakeY = float32(game.fxRng.value(-int32(50), int32(50))) / 100
 	}
 	/* SPAWNING POINTS */
 	if game.camera.y < game.furthestY {
@@ -1773,68 +1497,231 @@ func update(game *Game) {
 		game.furthestY = game.camera.y
 		game.skierPoints += pointsAdded
 		game.outerTreePoints += pointsAdded
-		game.crapPoints += pointsAdded
-		game.treePoints += pointsAdded
-		game.trapPoints += pointsAdded
-		game.rockPoints += pointsAdded
+		for _, name := range stageArchetypes {
+			game.spawnPoints[name] += pointsAdded
+		}
+	}
+	if simulating {
+		recordGhostFrame(game)
 	}
 	/* WIN IF WINNING */
 	if player.y <= 0 && !game.finished {
 		game.finished = true
-		rl.PlaySound(resources.win)
+		game.stats.finishTime = game.playTime
+		soundtrack.playSting(resources.win)
 		game.notificationText = "FINISHED!\nNow playing endless mode..."
 		game.notificationTimer.reset()
-		scores.wins += 1
-		if scores.fastestTime <= -1 {
-			scores.fastestTime = game.playTime
-		} else {
-			scores.fastestTime = min(scores.fastestTime, game.playTime)
-		}
-		if scores.fewestHits <= -1 {
-			scores.fewestHits = game.hits
-		} else {
-			scores.fewestHits = min(scores.fewestHits, game.hits)
+		if game.keepsRecords() {
+			scores.wins += 1
+			if scores.fastestTime <= -1 || game.playTime < scores.fastestTime {
+				ghost = game.ghostRecording
+				saveGhost(ghost)
+				game.recordingBest = true
+			}
+			if scores.fastestTime <= -1 {
+				scores.fastestTime = game.playTime
+			} else {
+				scores.fastestTime = min(scores.fastestTime, game.playTime)
+			}
+			if scores.fewestHits <= -1 {
+				scores.fewestHits = game.stats.hits
+			} else {
+				scores.fewestHits = min(scores.fewestHits, game.stats.hits)
+			}
+			saveScores()
 		}
-		saveScores()
+	}
+	if simulating {
+		checkReplay(game)
 	}
 	/* MENU INPUT */
 	if game.menuOpen {
-		if game.input.move.Y > 0 {
-			prev := game.menuSelection
-			game.menuSelection = min(1, game.menuSelection+1)
-			if game.menuSelection != prev {
-				rl.PlaySound(resources.click)
+		updateMenu(game)
+	}
+}
+
+/* pushes solid things apart and applies ice, damage, items and deaths for every overlapping pair */
+func resolveCollisions(game *Game, playerMomentum float32) {
+	player := game.player()
+	game.broadphase.build(&game.entitys, game.camera.y)
+	for i1 := range game.entitys.count() {
+		e1 := game.entitys.at(i1)
+		if !e1.hasBehavior(bDynamic|bSolid) || e1.hp <= 0 {
+			continue
+		}
+		candidates := game.broadphase.query(e1.getHitbox())
+		for i2 := candidates.next(0); i2 >= 0; i2 = candidates.next(i2 + 1) {
+			if i1 == i2 {
+				continue
 			}
-		} else if game.input.move.Y < 0 {
-			prev := game.menuSelection
-			game.menuSelection = max(0, game.menuSelection-1)
-			if game.menuSelection != prev {
-				rl.PlaySound(resources.click)
+			e2 := game.entitys.at(i2)
+			if e2.hp <= 0 {
+				continue
+			}
+			collision := aabbCollision(e1.getHitbox(), e2.getHitbox())
+			if collision.Width != 0 && collision.Height != 0 {
+				if e1 == player && e2.hasBehavior(bSolid) {
+					game.camera.shakeMagnitude += 30
+				}
+				tryIce(e1, e2, &game.stats)
+				tryIce(e2, e1, &game.stats)
+
+				if !e1.hasBehavior(bSmashEverything) && e2.hasBehavior(bSolid) && !e2.hasBehavior(bIced) {
+					displacement1 := -e1.vy / abs(e1.vy-e2.vy) * collision.Height
+					e1.y += displacement1
+					displacement2 := -e2.vy / abs(e2.vy-e1.vy) * collision.Height
+					e2.y += displacement2
+					// keep the grid in step, e1 may now reach things it didn't when the pass started
+					game.broadphase.move(i1, *e1)
+					game.broadphase.move(i2, *e2)
+					candidates = game.broadphase.query(e1.getHitbox())
+					e1.vy = rl.Clamp(-e1.vy, -100, 100)
+					e2.vy = rl.Clamp(-e2.vy, -100, 100)
+					if math.IsNaN(float64(e1.y)) || math.IsNaN(float64(e1.x)) {
+						panic("NaN position")
+					}
+
+				}
+
+				if !e1.hasBehavior(bInvincible) {
+					damaged := tryDamage(e1, e2, &game.stats)
+					if e1 == player && damaged {
+						game.healthBar.shakeMagnitude += 50
+						backend.playSound(resources.impact)
+					}
+				}
+				if !e2.hasBehavior(bInvincible) {
+					damaged := tryDamage(e2, e1, &game.stats)
+					if e2 == player && damaged {
+						game.healthBar.shakeMagnitude += 50
+						backend.playSound(resources.impact)
+					}
+				}
+				if (e1 == player || e2 == player) && player.hp <= 0 {
+					backend.stopSound(resources.scoop)
+					backend.stopMusic(resources.slideCenter)
+					backend.stopMusic(resources.slideSide)
+					backend.stopMusic(resources.slideBoost)
+					if game.keepsRecords() {
+						scores.lowest = min(scores.lowest, player.y)
+						saveScores()
+					}
+					endRun(game)
+					game.deathTimer.reset()
+				}
+				if (e1.hasBehavior(bDropsItem) && e1.hp <= 0) || (e2.hasBehavior(bDropsItem) && e2.hp <= 0) {
+					item := player.giveRandomItem(&game.stats)
+					switch item {
+					case itemHealth:
+						game.healthBar.shakeMagnitude += 50
+						which := game.fxRng.value(0, 5)
+						var txt string
+						switch which {
+						case 0:
+							txt = "DELICIOUS!"
+						case 1:
+							txt = "DELECTABLE!"
+						case 2:
+							txt = "SCRUMPTIOUS!"
+						case 3:
+							txt = "YUMMY!"
+						case 4:
+							txt = "MMMM!"
+						case 5:
+							txt = "TASTY!"
+						}
+						game.notificationText = txt
+					case itemBoost:
+						backend.playSound(resources.boost)
+						game.notificationText = "BOOST!"
+					}
+					game.notificationTimer.reset()
+					game.skierTimer.reset()
+				}
+				var vy float32
+				if e2 == player {
+					vy = playerMomentum
+				} else {
+					vy = 0
+				}
+				tryDeath(e1, vy, game.playTime, &game.stats, &game.fxRng)
+				if e1 == player {
+					vy = playerMomentum
+
+				} else {
+					vy = 0
+				}
+				tryDeath(e2, vy, game.playTime, &game.stats, &game.fxRng)
+				if !e1.hasBehavior(bDynamic|bSolid) || e1.hp <= 0 {
+					break
+				}
 			}
 		}
-		if game.input.action {
-			switch game.menuSelection {
-			case 0:
-				reset(game)
-			case 1:
-				game.quit = true
+	}
+}
+
+/* snowballs over a lava pit fizzle and iced things melt, both go up in steam. uses the broadphase from resolveCollisions */
+func meltOnLava(game *Game) {
+	for i := range game.entitys.count() {
+		lava := game.entitys.at(i)
+		if !lava.hasBehavior(bMelts) || lava.hp <= 0 {
+			continue
+		}
+		candidates := game.broadphase.query(lava.getHitbox())
+		for j := candidates.next(0); j >= 0; j = candidates.next(j + 1) {
+			entity := game.entitys.at(j)
+			fizzles := entity.hasBehavior(bCausesIce)
+			melts := entity.hasBehavior(bIced)
+			if entity == lava || entity.hp <= 0 || (!fizzles && !melts) {
+				continue
 			}
-			rl.PlaySound(resources.click)
+			collision := aabbCollision(lava.getHitbox(), entity.getHitbox())
+			if collision.Width == 0 || collision.Height == 0 {
+				continue
+			}
+			if fizzles && lava.archetype != nil && lava.archetype.FizzleSound != "" {
+				backend.playSoundAt(lava.archetype.fizzleSound, entity.x, entity.y)
+			} else if melts && lava.archetype != nil && lava.archetype.MeltSound != "" {
+				backend.playSoundAt(lava.archetype.meltSound, entity.x, entity.y)
+			}
+			if melts {
+				game.stats.melted += 1
+			}
+			entity.hp = 0
+			entity.behavior &^= bIced
+			entity.behavior |= bExplodesOnDeath
+			entity.explosionKind = dotSteam
+			entity.deathSound = nil
+			tryDeath(entity, 0, game.playTime, &game.stats, &game.fxRng)
 		}
 	}
 }
 
 func updateDraw(game *Game) {
 	update(game)
-	draw(*game)
+	backend.draw(*game)
 }
 
 func reset(game *Game) {
-	muted := game.muted
+	chosenSeed, seedChosen := game.chosenSeed, game.seedChosen
 	*game = Game{}
+	game.chosenSeed, game.seedChosen = chosenSeed, seedChosen
+	if game.seedChosen {
+		game.seed = game.chosenSeed
+	} else {
+		game.seed = randomSeed()
+	}
+	game.rng = newRng(game.seed, rngStreamGameplay)
+	game.fxRng = newRng(game.seed, rngStreamCosmetic)
+	game.recording = Replay{gameVersion: gameVersion, seed: game.seed}
+	game.recordingActive = true
 	// game.playTime = rl.GetTime()
-	addPlayer(game.entitys[:])
-	player := &game.entitys[entitysPlayerIndex]
+	game.entitys = newEntityPool(entitysMaxCount)
+	game.spawnPoints = map[string]float32{}
+	game.stage = -1
+	game.path = Path{seed: game.seed}
+	game.playerHandle = addPlayer(&game.entitys)
+	player := game.player()
 	game.camera.x = player.x
 	game.camera.y = player.y + cameraFollowDistance
 	game.deathTimer.max = 3
@@ -1843,34 +1730,38 @@ func reset(game *Game) {
 	game.boostTimer.max = boostTime
 	game.notificationTimer.max = 2
 	game.healthBar.fullness = 1
+	game.slideAudio.pitch = 1
 	game.furthestY = startingHeight
-	game.muted = muted
-	if !game.muted {
-		game.musicVolume = 1
-	}
+	game.musicVolume = 1
 
 	y := player.y
 	for ; y > player.y-viewDistance; y -= barrierDistance {
-		addBarriers(y, game.entitys[:])
+		addBarriers(y, &game.entitys)
 	}
 	game.lastBarrierY = y + barrierDistance
 }
 
 func initGame(game *Game) {
 	reset(game)
-	player := &game.entitys[entitysPlayerIndex]
+	player := game.player()
 	player.hp = 0
 	game.camera.y -= cameraFollowDistance
 	game.menuOpen = true
+	game.titleScreen = true
+	game.recordingActive = false
+	game.runRecorded = true // the title screen isn't a run
 
-	rl.InitWindow(windowWidth, windowHeight, "iced birds")
+	initScreen()
 	rl.InitAudioDevice()
 	rl.SetExitKey(0)
 	rl.SetTargetFPS(60)
 	loadResources()
 	loadScores()
+	loadHistory()
+	loadGhost()
+	loadConfig()
+	applyVolumes()
+	applyWindowSettings()
 
-	rl.PlayMusicStream(resources.music)
-	rl.SetMusicVolume(resources.musicMenu, 0)
-	rl.PlayMusicStream(resources.musicMenu)
+	soundtrack.start()
 }
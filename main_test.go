@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+/* the tests run the simulation the way -headless does, with no window or audio device */
+func TestMain(m *testing.M) {
+	headless = true
+	backend = headlessBackend{}
+	loadSpriteGroups()
+	loadArchetypes()
+	loadStages()
+	os.Exit(m.Run())
+}
+
+// seeds the tests that run the simulation go through
+var testSeeds = []uint64{1, 2, 3, 42, 1234, 99999, 271828, 314159, 600000, 999999999}
+
+/* iced things don't keep lava from being placed on top of them, the way they keep everything else off */
+func TestLavaSpawnsUnderIcedThings(t *testing.T) {
+	const y float32 = -20000
+	path := Path{seed: 1}
+	rng := newRng(1, rngStreamGameplay)
+	entitys := newEntityPool(2048)
+	// close enough together that every spot either archetype could try is taken
+	for x := float32(-1100); x <= 1100; x += 20 {
+		for dy := float32(50); dy >= -350; dy -= 50 {
+			slot, _ := entitys.alloc()
+			*slot = Entity{x: x, y: y + dy, hp: 1, behavior: bExists | bCanBeIced | bIced}
+		}
+	}
+	if spawnArchetype("tree", y, path, &entitys, &rng) {
+		t.Errorf("a tree was placed on top of something iced")
+	}
+	if !spawnArchetype("lava", y, path, &entitys, &rng) {
+		t.Errorf("lava couldn't be placed under the iced things")
+	}
+}
+
+/* something iced stays where it froze, and melts once there's lava under it */
+func TestIcedSkierMeltsOnLava(t *testing.T) {
+	game := Game{}
+	game.chosenSeed, game.seedChosen = 1, true
+	reset(&game)
+	player := game.player()
+	x, y := float32(600), player.y-400
+	addSkier(y, &game.entitys, &game.rng)
+	var skier *Entity
+	for i := range game.entitys.count() {
+		if entity := game.entitys.at(i); entity.hasBehavior(bSkier) && entity.y == y {
+			skier = entity
+		}
+	}
+	skier.x = x
+	skier.behavior |= bIced
+	for range 30 {
+		update(&game)
+	}
+	if skier.x != x || skier.y != y {
+		t.Fatalf("iced skier moved from %.0f, %.0f to %.0f, %.0f", x, y, skier.x, skier.y)
+	}
+	lava, _ := game.entitys.alloc()
+	*lava = archetypes["lava"].instantiate(x, y, 0, false)
+	update(&game)
+	if game.stats.melted != 1 || skier.hasBehavior(bIced) {
+		t.Errorf("%d melted, the iced skier should have melted on the lava", game.stats.melted)
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const menuPageMain int32 = 0
+const menuPageStats int32 = 1
+const menuPageBoards int32 = 2
+const menuPageInitials int32 = 3
+const menuPageSeed int32 = 4
+const menuPageControls int32 = 5
+const menuPageSettings int32 = 6
+
+const menuItemNewRun int32 = 0
+const menuItemSeed int32 = 1
+const menuItemSettings int32 = 2
+const menuItemControls int32 = 3
+const menuItemStats int32 = 4
+const menuItemBoards int32 = 5
+const menuItemQuit int32 = 6
+const menuItemCount int32 = 7
+
+// longest seed that still fits in a uint64
+const seedMaxDigits = 19
+
+var menuItemNames = [menuItemCount]string{"new run", "seed", "settings", "controls", "stats", "leaderboards", "quit game"}
+
+// how far a stick has to be pushed to count as a press in menus
+const menuStickThreshold float32 = 0.5
+
+/* direction the player just started pushing, -1, 0 or 1 on each axis, so holding a direction only moves once */
+func menuMovePressed(game *Game) rl.Vector2 {
+	return rl.Vector2{
+		X: menuAxisPressed(game.input.move.X, game.lastMenuMove.X),
+		Y: menuAxisPressed(game.input.move.Y, game.lastMenuMove.Y),
+	}
+}
+
+func menuAxisPressed(now, last float32) float32 {
+	if abs(last) >= menuStickThreshold || abs(now) < menuStickThreshold {
+		return 0
+	}
+	if now < 0 {
+		return -1
+	}
+	return 1
+}
+
+func updateMenu(game *Game) {
+	defer func() { game.lastMenuMove = game.input.move }()
+	switch game.menuPage {
+	case menuPageMain:
+		pressed := menuMovePressed(game)
+		if pressed.Y > 0 {
+			prev := game.menuSelection
+			game.menuSelection = min(menuItemCount-1, game.menuSelection+1)
+			if game.menuSelection != prev {
+				backend.playSound(resources.click)
+			}
+		} else if pressed.Y < 0 {
+			prev := game.menuSelection
+			game.menuSelection = max(0, game.menuSelection-1)
+			if game.menuSelection != prev {
+				backend.playSound(resources.click)
+			}
+		}
+		if game.input.action {
+			switch game.menuSelection {
+			case menuItemNewRun, menuItemQuit:
+				if game.player().hp > 0 {
+					endRun(game)
+				}
+				if game.pendingScore.waiting {
+					game.menuPage = menuPageInitials
+					game.afterInitials = game.menuSelection
+				} else if game.menuSelection == menuItemNewRun {
+					reset(game)
+				} else {
+					game.quit = true
+				}
+			case menuItemSeed:
+				game.menuPage = menuPageSeed
+				game.seedText = ""
+				if game.seedChosen {
+					game.seedText = strconv.FormatUint(game.chosenSeed, 10)
+				}
+			case menuItemSettings:
+				game.menuPage = menuPageSettings
+				game.settingsRow = 0
+			case menuItemControls:
+				game.menuPage = menuPageControls
+				game.controlsRow = 0
+				game.controlsSlot = 0
+			case menuItemStats:
+				game.menuPage = menuPageStats
+			case menuItemBoards:
+				game.menuPage = menuPageBoards
+			}
+			backend.playSound(resources.click)
+		}
+	case menuPageStats:
+		if game.input.action || game.input.pause {
+			game.menuPage = menuPageMain
+			backend.playSound(resources.click)
+		}
+	case menuPageSeed:
+		updateSeedPage(game)
+	case menuPageBoards:
+		updateBoardsPage(game)
+	case menuPageInitials:
+		updateInitialsPage(game)
+	case menuPageControls:
+		updateControlsPage(game)
+	case menuPageSettings:
+		updateSettingsPage(game)
+	}
+}
+
+func drawMainMenu(game Game) {
+	menuY := float32(470)
+	snowballWidth := 24
+
+	strLow := fmt.Sprintf("Lowest: %d m", int32(scores.lowest/100))
+	strQuick := fmt.Sprintf("Quickest: %d s", int32(scores.fastestTime))
+	strWin := fmt.Sprintf("Wins: %d", scores.wins)
+	width := float32(237.5 - 16)
+	for _, name := range menuItemNames {
+		width = max(width, measureText(name)+float32(snowballWidth+8))
+	}
+	width = max(width, measureText(strLow))
+	width = max(width, measureText(strQuick))
+	width = max(width, measureText(strWin))
+	width += 16
+	itemsHeight := float32(menuItemCount) * 30
+	var height float32
+	if scores.wins > 0 {
+		height = itemsHeight + 90
+	} else {
+		height = itemsHeight + 30
+	}
+	rl.DrawRectangleRec(rl.Rectangle{X: 12, Y: menuY - 8, Width: width + 8, Height: height + 8}, rl.Black)
+	rl.DrawRectangleRec(rl.Rectangle{X: 16, Y: menuY - 4, Width: width, Height: height}, rl.White)
+
+	buttonWidth := width - (float32(snowballWidth) + 8) - 4
+	for i, name := range menuItemNames {
+		y := menuY + float32(i)*30
+		rl.DrawRectangleRec(rl.Rectangle{X: 20, Y: y, Width: buttonWidth, Height: 24}, colorLightGrey)
+		drawText(name, 20+buttonWidth/2-measureText(name)/2, y)
+	}
+	/* CURSOR */
+	drawTextureRotating(resources.snowball[0].texture, rl.Rectangle{X: 20 + width - (float32(snowballWidth) + 4) - 4, Y: menuY + float32(game.menuSelection)*30, Width: float32(snowballWidth), Height: float32(snowballWidth)}, snowballRotationSpeed*float32(game.playTime))
+
+	/* SCORES */
+	if scores.wins > 0 {
+		drawText(strLow, 24, menuY+itemsHeight)
+		drawText(strQuick, 24, menuY+itemsHeight+30)
+		drawText(strWin, 24, menuY+itemsHeight+60)
+	} else {
+		drawText(strLow, 24, menuY+itemsHeight)
+	}
+	if scoresLoadError != nil {
+		drawText("Scores file unreadable!", 24, menuY+height+12)
+	}
+	if !game.titleScreen {
+		drawText(fmt.Sprintf("Seed: %d", game.seed), 24, menuY-40)
+	}
+}
+
+/* typing digits picks the seed for every run after this, leaving it empty goes back to random */
+func updateSeedPage(game *Game) {
+	if game.input.char >= '0' && game.input.char <= '9' && len(game.seedText) < seedMaxDigits {
+		game.seedText += string(game.input.char)
+		backend.playSound(resources.click)
+	}
+	if game.input.erase && len(game.seedText) > 0 {
+		game.seedText = game.seedText[:len(game.seedText)-1]
+		backend.playSound(resources.click)
+	}
+	// without a keyboard, right adds a digit, up and down roll the last one and left takes it off
+	pressed := menuMovePressed(game)
+	if pressed.X > 0 && len(game.seedText) < seedMaxDigits {
+		game.seedText += "0"
+		backend.playSound(resources.click)
+	} else if pressed.X < 0 && len(game.seedText) > 0 {
+		game.seedText = game.seedText[:len(game.seedText)-1]
+		backend.playSound(resources.click)
+	} else if pressed.Y != 0 && len(game.seedText) > 0 {
+		last := int32(game.seedText[len(game.seedText)-1]-'0') - int32(pressed.Y)
+		last = (last + 10) % 10
+		game.seedText = game.seedText[:len(game.seedText)-1] + string(rune('0'+last))
+		backend.playSound(resources.click)
+	}
+	if game.input.action {
+		seed, err := strconv.ParseUint(game.seedText, 10, 64)
+		game.seedChosen = err == nil
+		game.chosenSeed = seed
+		game.menuPage = menuPageMain
+		backend.playSound(resources.click)
+	} else if game.input.pause {
+		game.menuPage = menuPageMain
+		backend.playSound(resources.click)
+	}
+}
+
+func drawSeedPage(game Game) {
+	panel := rl.Rectangle{X: 24, Y: 210, Width: float32(virtualWidth) - 48, Height: 200}
+	rl.DrawRectangleRec(rl.Rectangle{X: panel.X - 4, Y: panel.Y - 4, Width: panel.Width + 8, Height: panel.Height + 8}, rl.Black)
+	rl.DrawRectangleRec(panel, rl.White)
+
+	title := "Seed for next runs"
+	drawText(title, float32(virtualWidth)/2-measureText(title)/2, panel.Y+8)
+	text := game.seedText
+	if text == "" {
+		text = "random"
+	}
+	rl.DrawRectangleRec(rl.Rectangle{X: panel.X + 12, Y: panel.Y + 70, Width: panel.Width - 24, Height: 32}, colorLightGrey)
+	drawText(text, float32(virtualWidth)/2-measureText(text)/2, panel.Y+74)
+	hint := "type or pick digits then press enter"
+	drawText(hint, float32(virtualWidth)/2-measureText(hint)/2, panel.Y+panel.Height-34)
+}
+
+/* lifetime totals and the last few runs */
+func drawStatsPage() {
+	panel := rl.Rectangle{X: 24, Y: 210, Width: float32(virtualWidth) - 48, Height: 510}
+	rl.DrawRectangleRec(rl.Rectangle{X: panel.X - 4, Y: panel.Y - 4, Width: panel.Width + 8, Height: panel.Height + 8}, rl.Black)
+	rl.DrawRectangleRec(panel, rl.White)
+
+	x := panel.X + 12
+	y := panel.Y + 8
+	line := func(label string, value string) {
+		drawText(label, x, y)
+		drawText(value, panel.X+panel.Width-12-measureText(value), y)
+		y += 28
+	}
+	drawText("Lifetime", x, y)
+	y += 34
+	line("Runs", fmt.Sprintf("%d", lifetime.runs))
+	line("Wins", fmt.Sprintf("%d", lifetime.wins))
+	line("Time skied", fmt.Sprintf("%d s", int64(lifetime.playTime)))
+	line("Hits taken", fmt.Sprintf("%d", lifetime.hits))
+	line("Penguins frozen", fmt.Sprintf("%d", lifetime.frozen))
+	line("Obstacles smashed", fmt.Sprintf("%d", lifetime.smashed))
+	line("Items eaten", fmt.Sprintf("%d", lifetime.items))
+	line("Melted in lava", fmt.Sprintf("%d", lifetime.melted))
+	if scores.fewestHits >= 0 {
+		line("Fewest hits on a win", fmt.Sprintf("%d", scores.fewestHits))
+	}
+
+	y += 10
+	drawText("Recent runs", x, y)
+	y += 34
+	if len(history) == 0 {
+		drawText("No runs yet", x, y)
+	}
+	for i := len(history) - 1; i >= max(0, len(history)-historyRecentCount); i-- {
+		record := history[i]
+		var result string
+		if record.finishTime >= 0 {
+			result = fmt.Sprintf("finished in %d s", int32(record.finishTime))
+		} else {
+			result = fmt.Sprintf("%d m", int32(record.lowest/100))
+		}
+		line(fmt.Sprintf("%d s  %d hits  %s", int32(record.playTime), record.hits, causeName(record.cause)), result)
+	}
+}
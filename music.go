@@ -0,0 +1,235 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+MUSIC
+
+tracks are listed in music.json, and like archetypes.json a copy in the -resources folder replaces the built in one.
+
+	name        what stages and the code call it, run and menu have to be there
+	bpm         beats per minute, for the beat clock, leave out if nothing keys off it
+	loopStart   seconds into the track to go back to once it gets to the end or loopEnd
+	loopEnd     seconds into the track to loop at, leave out to loop at the end of the file
+	layers      layer name to file in resources/audio, all played in sync. base is always heard,
+	            intensity fades in while boosting or on the last hit point
+
+the soundtrack plays one track for the run and the menu track on top, and fades between the two as the menu opens
+and closes. a sting ducks the run's track, and once it's over whichever track is current starts again from the top.
+*/
+
+const tracksFilename = "music.json"
+
+//go:embed music.json
+var defaultTracks []byte
+
+var requiredTracks = []string{"run", "menu"}
+
+const trackLayerBase = "base"
+const trackLayerIntensity = "intensity"
+
+// how much of the way to their targets the layers get in a second
+const trackLayerFadeSpeed float32 = 2
+
+// how far a track's position has to jump back to count as the stream looping on its own
+const trackWrapThreshold float32 = 0.5
+
+type Track struct {
+	Name      string            `json:"name"`
+	Bpm       float32           `json:"bpm"`
+	LoopStart float32           `json:"loopStart"`
+	LoopEnd   float32           `json:"loopEnd"`
+	Layers    map[string]string `json:"layers"`
+
+	// filled in once loaded
+	layers map[string]rl.Music
+}
+
+var tracks = map[string]*Track{}
+
+type Soundtrack struct {
+	track        *Track // the run's track
+	menu         *Track
+	layerVolumes map[string]float32 // of the run's track, before the menu fade
+	lastTime     float32            // where the run's track was last frame, to notice it wrapping
+	menuLastTime float32
+	sting        *Sound // playing instead of the run's track until it finishes
+}
+
+var soundtrack = Soundtrack{}
+
+/* reads the track list, but not the music itself */
+func loadTracks() {
+	loadDefinitions(tracksFilename, defaultTracks, func(data []byte) error {
+		parsed, err := parseTracks(data)
+		if err == nil {
+			tracks = parsed
+		}
+		return err
+	})
+}
+
+func parseTracks(data []byte) (map[string]*Track, error) {
+	list := []*Track{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	parsed := map[string]*Track{}
+	for _, track := range list {
+		if track.Name == "" {
+			return nil, errors.New("track with no name")
+		}
+		if parsed[track.Name] != nil {
+			return nil, fmt.Errorf("track %s is defined twice", track.Name)
+		}
+		if track.Layers[trackLayerBase] == "" {
+			return nil, fmt.Errorf("track %s has no %s layer", track.Name, trackLayerBase)
+		}
+		if track.LoopStart < 0 || (track.LoopEnd != 0 && track.LoopEnd <= track.LoopStart) {
+			return nil, fmt.Errorf("track %s has loop points out of order", track.Name)
+		}
+		parsed[track.Name] = track
+	}
+	for _, name := range requiredTracks {
+		if parsed[name] == nil {
+			return nil, fmt.Errorf("track %s is missing", name)
+		}
+	}
+	return parsed, nil
+}
+
+/* loads every layer as its own stream, needs the audio device */
+func loadTrackResources() {
+	for _, track := range tracks {
+		track.layers = map[string]rl.Music{}
+		for layer, filename := range track.Layers {
+			// tracks sharing a file still get their own stream, so switching between them starts over
+			track.layers[layer] = loadStreamLayer(filename, track.Name+" "+layer, soundCategoryMusic)
+		}
+	}
+	for _, stage := range stages {
+		if stage.Music != "" && tracks[stage.Music] == nil {
+			rl.TraceLog(rl.LogWarning, "Stage %s has unknown track %s, using run", stage.Name, stage.Music)
+		}
+	}
+}
+
+/* the track called name, or the run's default track if there isn't one */
+func findTrack(name string) *Track {
+	if track, ok := tracks[name]; ok {
+		return track
+	}
+	return tracks["run"]
+}
+
+/* starts the run's and menu's tracks, the menu's silent until the menu opens */
+func (soundtrack *Soundtrack) start() {
+	soundtrack.menu = tracks["menu"]
+	soundtrack.startTrack(soundtrack.menu)
+	soundtrack.play(tracks["run"])
+}
+
+/* switches the run to track from the top, unless it's already playing */
+func (soundtrack *Soundtrack) play(track *Track) {
+	if track == nil || track == soundtrack.track {
+		return
+	}
+	if soundtrack.track != nil {
+		for _, music := range soundtrack.track.layers {
+			backend.stopMusic(music)
+		}
+	}
+	soundtrack.track = track
+	soundtrack.startTrack(track)
+	soundtrack.lastTime = 0
+	soundtrack.layerVolumes = map[string]float32{trackLayerBase: 1}
+}
+
+func (soundtrack *Soundtrack) startTrack(track *Track) {
+	for _, music := range track.layers {
+		backend.setMusicVolume(music, 0)
+		backend.playMusic(music)
+	}
+}
+
+/* ducks the run's track for sound, then starts it again from the top */
+func (soundtrack *Soundtrack) playSting(sound *Sound) {
+	soundtrack.sting = sound
+	backend.playSound(sound)
+}
+
+/* keeps the streams fed, loops them and sets every layer's volume. call once a frame */
+func (soundtrack *Soundtrack) update(game *Game, frameTime float32) {
+	// nothing's loaded without a window
+	if soundtrack.track == nil || soundtrack.menu == nil {
+		return
+	}
+	if soundtrack.sting != nil && !backend.isSoundPlaying(soundtrack.sting) {
+		soundtrack.sting = nil
+		track := soundtrack.track
+		soundtrack.track = nil
+		soundtrack.play(track)
+	}
+
+	player := game.player()
+	intensity := float32(0)
+	if player.hp > 0 && (player.boostTimer.time > 0 || player.hp == 1) {
+		intensity = 1
+	}
+	fade := min(1, trackLayerFadeSpeed*frameTime)
+	soundtrack.layerVolumes[trackLayerIntensity] += (intensity - soundtrack.layerVolumes[trackLayerIntensity]) * fade
+
+	soundtrack.lastTime = loopTrack(soundtrack.track, soundtrack.lastTime)
+	soundtrack.menuLastTime = loopTrack(soundtrack.menu, soundtrack.menuLastTime)
+	for layer, music := range soundtrack.track.layers {
+		volume := soundtrack.layerVolumes[layer] * game.musicVolume
+		if soundtrack.sting != nil {
+			volume = 0
+		}
+		backend.setMusicVolume(music, volume)
+	}
+	for layer, music := range soundtrack.menu.layers {
+		if layer == trackLayerBase {
+			backend.setMusicVolume(music, game.musicMenuVolume)
+		}
+	}
+}
+
+/*
+feeds every layer of track and sends them all back to loopStart at the loop point. a stream that reaches the end
+of its file loops back to 0 by itself, which is noticed by its position jumping back. returns the new position
+*/
+func loopTrack(track *Track, lastTime float32) float32 {
+	base := track.layers[trackLayerBase]
+	for _, music := range track.layers {
+		backend.updateMusic(music)
+	}
+	time := backend.musicTimePlayed(base)
+	wrapped := time < lastTime-trackWrapThreshold
+	if (track.LoopEnd > 0 && time >= track.LoopEnd) || (wrapped && track.LoopStart > 0) {
+		position := track.LoopStart
+		if wrapped {
+			position += time
+		}
+		for _, music := range track.layers {
+			backend.seekMusic(music, position)
+		}
+		time = position
+	}
+	return time
+}
+
+/* beats into the run's track since it started, for keeping things in time with it. 0 for a track with no bpm */
+func (soundtrack *Soundtrack) beat() float32 {
+	if soundtrack.track == nil {
+		return 0
+	}
+	return backend.musicTimePlayed(soundtrack.track.layers[trackLayerBase]) * soundtrack.track.Bpm / 60
+}
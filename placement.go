@@ -0,0 +1,143 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+PLACEMENT
+
+a path winds down the hill and nothing that hurts or blocks the bear is put on it, so there's always a way through.
+its middle is 1D value noise, a random point every pathStep of altitude eased between with smoothstep. the points
+are kept far enough in from the edges that the steepest the path gets is about pathStep apart for the whole width
+of the hill, which is well within how fast the bear can steer sideways.
+*/
+
+const rngStreamPath uint64 = 3
+
+const pathStep float32 = 3000
+const pathHalfWidth float32 = float32(playerWidth) * 1.5
+
+// the bear moves sideways at twice its wish speed while going down at about its wish speed
+const playerReachSlope float32 = 2
+
+// how many spots an obstacle tries before it gives up on being placed this frame
+const placementAttempts = 8
+
+type Path struct {
+	seed uint64
+}
+
+/* x of the middle of the path at altitude y */
+func (path Path) center(y float32) float32 {
+	step := math.Floor(float64(y / pathStep))
+	t := y/pathStep - float32(step)
+	t = t * t * (3 - 2*t)
+	a := path.point(int64(step))
+	b := path.point(int64(step) + 1)
+	return a + (b-a)*t
+}
+
+func (path Path) point(index int64) float32 {
+	rng := newRng(path.seed^uint64(index)*0x9e3779b97f4a7c15, rngStreamPath)
+	limit := int32(hillWidth/2 - float32(playerWidth) - pathHalfWidth)
+	return float32(rng.value(-limit, limit))
+}
+
+/* whether an entity gets in the bear's way, and so has to stay off the path */
+func (entity Entity) blocksPath() bool {
+	if entity.hitbox.Width == 0 || entity.hasBehavior(bIced) || entity.hasBehavior(bDynamic) || entity.hasBehavior(bSkier) {
+		return false
+	}
+	return entity.hasBehavior(bSolid) || entity.damage > 0
+}
+
+/*
+a random x between -spread and spread for something with hitbox at altitude y, skipping over the path
+if it blocks. false if the path takes up the whole range
+*/
+func placeOffPath(path Path, y float32, spread int32, hitbox rl.Rectangle, blocks bool, rng *Rng) (float32, bool) {
+	if !blocks {
+		return float32(rng.value(-spread, spread)), true
+	}
+	center := path.center(y)
+	// the hitbox can't reach into the path from either side, these are the first and last x where it would
+	firstBlocked := int32(math.Floor(float64(center-pathHalfWidth-(hitbox.X+hitbox.Width)))) + 1
+	lastBlocked := int32(math.Ceil(float64(center+pathHalfWidth-hitbox.X))) - 1
+	firstBlocked = max(firstBlocked, -spread)
+	lastBlocked = min(lastBlocked, spread)
+	blocked := max(0, lastBlocked-firstBlocked+1)
+	// both ends of the range count
+	allowed := 2*spread + 1 - blocked
+	if allowed <= 0 {
+		return 0, false
+	}
+	x := -spread + rng.value(0, allowed-1)
+	if blocked > 0 && x >= firstBlocked {
+		x += blocked
+	}
+	return float32(x), true
+}
+
+/*
+checks there's a way down from top to bottom that stays clear of everything that blocks the path, for a bear that
+can move playerReachSlope sideways for every unit down. the slice is cut into rows a hitbox tall and columns,
+and the set of columns the bear could be in is carried down row by row.
+*/
+func checkPassable(entitys *EntityPool, top, bottom float32) bool {
+	const columnWidth = 10
+	const rowHeight = 25
+	columnCount := int(hillWidth / columnWidth)
+	reach := int(math.Ceil(float64(playerReachSlope * rowHeight / columnWidth)))
+	halfBear := float32(playerWidth) / 2
+
+	reachable := make([]bool, columnCount)
+	for i := range reachable {
+		reachable[i] = true
+	}
+	next := make([]bool, columnCount)
+	free := make([]bool, columnCount)
+	for rowTop := top; rowTop > bottom; rowTop -= rowHeight {
+		rowBottom := rowTop - rowHeight
+		for i := range free {
+			x := -hillWidth/2 + (float32(i)+0.5)*columnWidth
+			free[i] = x >= -hillWidth/2+float32(playerWidth) && x <= hillWidth/2-float32(playerWidth)
+		}
+		for i := range entitys.count() {
+			entity := entitys.at(i)
+			if entity.behavior == 0 || entity.hp <= 0 || !entity.blocksPath() {
+				continue
+			}
+			hitbox := entity.getHitbox()
+			if hitbox.Y > rowTop || hitbox.Y+hitbox.Height < rowBottom {
+				continue
+			}
+			first := int(math.Floor(float64((hitbox.X - halfBear + hillWidth/2) / columnWidth)))
+			last := int(math.Ceil(float64((hitbox.X + hitbox.Width + halfBear + hillWidth/2) / columnWidth)))
+			for column := max(0, first); column < min(columnCount, last); column++ {
+				free[column] = false
+			}
+		}
+		anyReachable := false
+		for i := range next {
+			next[i] = false
+			if !free[i] {
+				continue
+			}
+			for j := max(0, i-reach); j <= min(columnCount-1, i+reach); j++ {
+				if reachable[j] {
+					next[i] = true
+					anyReachable = true
+					break
+				}
+			}
+		}
+		if !anyReachable {
+			return false
+		}
+		reachable, next = next, reachable
+	}
+	return true
+}
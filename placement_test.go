@@ -0,0 +1,84 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/* every archetype that has to stay off the path */
+func blockingArchetypes() []string {
+	names := []string{}
+	for name, archetype := range archetypes {
+		if archetype.instantiate(0, 0, 0, false).blocksPath() {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+/* whether x keeps hitbox clear of the path, touching its edge is fine */
+func clearOfPath(path Path, x, y float32, hitbox rl.Rectangle) bool {
+	center := path.center(y)
+	return x+hitbox.X+hitbox.Width <= center-pathHalfWidth || x+hitbox.X >= center+pathHalfWidth
+}
+
+/* every whole x in the spread that's clear of the path should come up, and nothing else should */
+func TestPlaceOffPathUsesEverySpot(t *testing.T) {
+	const spread int32 = 150
+	hitbox := rl.Rectangle{X: -20, Y: -12, Width: 40, Height: 25}
+	for _, seed := range testSeeds {
+		path := Path{seed: seed}
+		rng := newRng(seed, rngStreamGameplay)
+		// somewhere the path runs through the middle of the spread, so there's a gap on both sides of it
+		y := float32(0)
+		for center := path.center(y); center < -50 || center > 50; center = path.center(y) {
+			y -= 100
+		}
+		legal := map[int32]bool{}
+		for x := -spread; x <= spread; x++ {
+			if clearOfPath(path, float32(x), y, hitbox) {
+				legal[x] = true
+			}
+		}
+		placed := map[int32]bool{}
+		for range 100 * spread {
+			x, ok := placeOffPath(path, y, spread, hitbox, true, &rng)
+			if !ok {
+				t.Fatalf("seed %d: no spot found with %d legal ones", seed, len(legal))
+			}
+			if !legal[int32(x)] {
+				t.Fatalf("seed %d: placed at %.0f, on the path at %.1f", seed, x, path.center(y))
+			}
+			placed[int32(x)] = true
+		}
+		for x := range legal {
+			if !placed[x] {
+				t.Errorf("seed %d: %d is clear of the path but never picked", seed, x)
+			}
+		}
+	}
+}
+
+/* a slice of hill packed as full of obstacles as they'll go still has to have a way down */
+func TestFilledSliceIsPassable(t *testing.T) {
+	const top float32 = -20000
+	const bottom = top - viewDistance
+	names := blockingArchetypes()
+	for _, seed := range testSeeds {
+		path := Path{seed: seed}
+		rng := newRng(seed, rngStreamGameplay)
+		entitys := newEntityPool(entitysMaxCount)
+		// spawns land up to 300 below where they're asked for, so stop that far up to keep them in the slice
+		for y := top; y > bottom+300; y -= 10 {
+			for _, name := range names {
+				spawnArchetype(name, y, path, &entitys, &rng)
+			}
+		}
+		if !checkPassable(&entitys, top, bottom) {
+			t.Errorf("seed %d: no way through %d obstacles", seed, entitys.live)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+REPLAY FILE FORMAT
+
+everything is little endian
+
+	magic        [4]byte  "IBRP"
+	version      uint16   replayVersion
+	gameVersion  uint8 length, then that many bytes
+	seed         uint64
+	runs         uint32 count, then count * { repeat uint16, moveX int8, moveY int8, buttons uint8 }
+	checkpoints  uint32 count, then count * { frame uint32, hash uint32 }
+	final        ReplaySummary, in the order of its fields()
+	checksum     uint32   crc32 (IEEE) of every byte before it
+
+only frames where the simulation moves forward are kept, so pausing doesn't show up.
+each run is one input held for repeat frames in a row.
+
+every run is saved to the replays folder, named by when it was saved, and only the newest replaysKept are kept.
+the run that set the fastest time is copied to best.replay as well, which stays until a faster one replaces it.
+*/
+
+const replayMagic = "IBRP"
+const replayVersion uint16 = 1
+
+// how many recorded frames between state hashes
+const replayCheckpointInterval = 60
+
+// four hours, far longer than any run. a file asking for more frames than this is taken as corrupt
+const replayMaxFrames = 4 * 60 * 60 * 60
+
+const replaysKept = 20
+const replayBestFilename = "best.replay"
+
+const replayButtonAction uint8 = 1 << 0
+
+type ReplayFrame struct {
+	moveX, moveY int8
+	buttons      uint8
+}
+
+type ReplayCheckpoint struct {
+	frame uint32
+	hash  uint32
+}
+
+/* where the run ended up, compared once playback runs out of frames */
+type ReplaySummary struct {
+	playTime float64
+	y        float32
+	hp       int32
+	hits     int16
+}
+
+func (summary *ReplaySummary) fields() []any {
+	return []any{&summary.playTime, &summary.y, &summary.hp, &summary.hits}
+}
+
+type Replay struct {
+	gameVersion string
+	seed        uint64
+	frames      []ReplayFrame
+	checkpoints []ReplayCheckpoint
+	final       ReplaySummary
+	unverified  bool // played back without checking it against checkpoints or final
+}
+
+/* snaps move to what a replay can store, so a live run sees exactly what its playback will */
+func (input *Input) quantize() {
+	input.move.X = float32(quantizeAxis(input.move.X)) / 127
+	input.move.Y = float32(quantizeAxis(input.move.Y)) / 127
+}
+
+func quantizeAxis(value float32) int8 {
+	return int8(math.Round(float64(rl.Clamp(value, -1, 1)) * 127))
+}
+
+func makeReplayFrame(input Input) ReplayFrame {
+	frame := ReplayFrame{moveX: quantizeAxis(input.move.X), moveY: quantizeAxis(input.move.Y)}
+	if input.action {
+		frame.buttons |= replayButtonAction
+	}
+	return frame
+}
+
+func (frame ReplayFrame) apply(input *Input) {
+	input.move = rl.Vector2{X: float32(frame.moveX) / 127, Y: float32(frame.moveY) / 127}
+	input.action = frame.buttons&replayButtonAction != 0
+}
+
+func summarize(game *Game) ReplaySummary {
+	player := *game.player()
+	return ReplaySummary{playTime: game.playTime, y: player.y, hp: player.hp, hits: game.stats.hits}
+}
+
+/* hash of everything that decides how the run goes, cosmetic state is left out */
+func hashGameState(game *Game) uint32 {
+	hash := fnv.New32a()
+	values := []float32{}
+	for i := range game.entitys.count() {
+		entity := game.entitys.at(i)
+		if entity.behavior == 0 {
+			continue
+		}
+		values = append(values, entity.x, entity.y, entity.vx, entity.vy, entity.wishSpeed, float32(entity.hp))
+	}
+	binary.Write(hash, binary.LittleEndian, values)
+	binary.Write(hash, binary.LittleEndian, game.stats.hits)
+	return hash.Sum32()
+}
+
+/* called once for every frame the simulation moves forward, before anything reads input */
+func stepReplay(game *Game) {
+	if game.replaying {
+		if game.playbackFrame < len(game.playback.frames) {
+			game.playback.frames[game.playbackFrame].apply(&game.input)
+			game.playbackFrame += 1
+		}
+		return
+	}
+	if game.recordingActive {
+		if len(game.recording.frames) >= replayMaxFrames {
+			// anything longer couldn't be loaded back, so the run so far is saved as it is
+			finishRecording(game)
+			return
+		}
+		game.recording.frames = append(game.recording.frames, makeReplayFrame(game.input))
+	}
+}
+
+/* called after a frame the simulation moved forward in, once everything has settled */
+func checkReplay(game *Game) {
+	if game.recordingActive {
+		frame := len(game.recording.frames)
+		if frame > 0 && frame%replayCheckpointInterval == 0 {
+			game.recording.checkpoints = append(game.recording.checkpoints, ReplayCheckpoint{frame: uint32(frame), hash: hashGameState(game)})
+		}
+		return
+	}
+	if !game.replaying || game.playbackDone || game.playback.unverified {
+		return
+	}
+	checkpoints := game.playback.checkpoints
+	for game.playbackCheckpoint < len(checkpoints) && int(checkpoints[game.playbackCheckpoint].frame) <= game.playbackFrame {
+		checkpoint := checkpoints[game.playbackCheckpoint]
+		game.playbackCheckpoint += 1
+		if int(checkpoint.frame) == game.playbackFrame && checkpoint.hash != hashGameState(game) {
+			replayDiverged(game)
+		}
+	}
+	if game.playbackFrame >= len(game.playback.frames) {
+		game.playbackDone = true
+		if summarize(game) != game.playback.final {
+			replayDiverged(game)
+		}
+		if game.replayMismatch {
+			game.notificationText = "REPLAY DIVERGED!"
+		} else {
+			game.notificationText = "REPLAY FINISHED"
+		}
+		game.notificationTimer.reset()
+	}
+}
+
+func replayDiverged(game *Game) {
+	if game.replayMismatch {
+		return
+	}
+	game.replayMismatch = true
+	game.replayMismatchTime = game.playTime
+	rl.TraceLog(rl.LogWarning, "Replay diverged from the recording at frame %d", game.playbackFrame)
+}
+
+/* keeps whatever's already recorded and stops adding to it */
+func finishRecording(game *Game) {
+	if !game.recordingActive {
+		return
+	}
+	game.recordingActive = false
+	game.recording.final = summarize(game)
+	saveReplay(game.recording, game.recordingBest)
+}
+
+func startPlayback(game *Game, replay Replay) {
+	chosenSeed, seedChosen := game.chosenSeed, game.seedChosen
+	game.chosenSeed, game.seedChosen = replay.seed, true
+	reset(game)
+	game.chosenSeed, game.seedChosen = chosenSeed, seedChosen
+	game.recordingActive = false
+	game.replaying = true
+	game.playback = replay
+	game.menuOpen = false
+	if replay.gameVersion != gameVersion {
+		game.notificationText = fmt.Sprintf("Replay is from version %s", replay.gameVersion)
+		game.notificationTimer.reset()
+	}
+}
+
+func replaysDir() string {
+	return dataDir + "replays" + string(filepath.Separator)
+}
+
+/* saves replay with the others, and as best.replay too if best is set */
+func saveReplay(replay Replay, best bool) {
+	data := encodeReplay(replay)
+	err := os.MkdirAll(replaysDir(), 0o755)
+	if err == nil {
+		filename := fmt.Sprintf("%s%s-%d.replay", replaysDir(), time.Now().Format("20060102-150405"), replay.seed)
+		err = writeFileAtomic(filename, data)
+	}
+	if err == nil && best {
+		err = writeFileAtomic(replaysDir()+replayBestFilename, data)
+	}
+	if err != nil {
+		rl.TraceLog(rl.LogError, "Failed to save replay: %v", err)
+	}
+	pruneReplays()
+}
+
+/* deletes all but the newest replaysKept replays. best.replay isn't counted and is never deleted */
+func pruneReplays() {
+	entries, err := os.ReadDir(replaysDir())
+	if err != nil {
+		return
+	}
+	// named by when they were saved, and ReadDir sorts by name, so these are oldest first
+	saved := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && entry.Name() != replayBestFilename && filepath.Ext(entry.Name()) == ".replay" {
+			saved = append(saved, entry.Name())
+		}
+	}
+	for _, name := range saved[:max(0, len(saved)-replaysKept)] {
+		if err := os.Remove(replaysDir() + name); err != nil {
+			rl.TraceLog(rl.LogWarning, "Failed to delete old replay %s: %v", name, err)
+		}
+	}
+}
+
+func loadReplay(filename string) (Replay, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Replay{}, err
+	}
+	return decodeReplay(data)
+}
+
+func encodeReplay(replay Replay) []byte {
+	buffer := bytes.Buffer{}
+	buffer.WriteString(replayMagic)
+	binary.Write(&buffer, binary.LittleEndian, replayVersion)
+	buffer.WriteByte(uint8(len(replay.gameVersion)))
+	buffer.WriteString(replay.gameVersion)
+	binary.Write(&buffer, binary.LittleEndian, replay.seed)
+
+	type run struct {
+		repeat uint16
+		frame  ReplayFrame
+	}
+	runs := []run{}
+	for _, frame := range replay.frames {
+		last := len(runs) - 1
+		if last >= 0 && runs[last].frame == frame && runs[last].repeat < math.MaxUint16 {
+			runs[last].repeat += 1
+		} else {
+			runs = append(runs, run{repeat: 1, frame: frame})
+		}
+	}
+	binary.Write(&buffer, binary.LittleEndian, uint32(len(runs)))
+	for _, run := range runs {
+		binary.Write(&buffer, binary.LittleEndian, run.repeat)
+		binary.Write(&buffer, binary.LittleEndian, run.frame.moveX)
+		binary.Write(&buffer, binary.LittleEndian, run.frame.moveY)
+		buffer.WriteByte(run.frame.buttons)
+	}
+
+	binary.Write(&buffer, binary.LittleEndian, uint32(len(replay.checkpoints)))
+	for _, checkpoint := range replay.checkpoints {
+		binary.Write(&buffer, binary.LittleEndian, checkpoint.frame)
+		binary.Write(&buffer, binary.LittleEndian, checkpoint.hash)
+	}
+	for _, field := range replay.final.fields() {
+		binary.Write(&buffer, binary.LittleEndian, field)
+	}
+	binary.Write(&buffer, binary.LittleEndian, crc32.ChecksumIEEE(buffer.Bytes()))
+	return buffer.Bytes()
+}
+
+var errReplayCorrupt = errors.New("replay file is corrupt")
+
+func decodeReplay(data []byte) (Replay, error) {
+	replay := Replay{}
+	if len(data) < len(replayMagic)+2+4 || !bytes.HasPrefix(data, []byte(replayMagic)) {
+		return replay, errReplayCorrupt
+	}
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return replay, errReplayCorrupt
+	}
+	reader := bytes.NewReader(body[len(replayMagic):])
+	var version uint16
+	binary.Read(reader, binary.LittleEndian, &version)
+	if version > replayVersion {
+		return replay, fmt.Errorf("replay file is from a newer version (%d)", version)
+	}
+	versionLength, _ := reader.ReadByte()
+	gameVersionBytes := make([]byte, versionLength)
+	reader.Read(gameVersionBytes)
+	replay.gameVersion = string(gameVersionBytes)
+	binary.Read(reader, binary.LittleEndian, &replay.seed)
+
+	var runCount uint32
+	binary.Read(reader, binary.LittleEndian, &runCount)
+	if int64(runCount)*5 > int64(reader.Len()) {
+		return replay, errReplayCorrupt
+	}
+	for range runCount {
+		var repeat uint16
+		frame := ReplayFrame{}
+		binary.Read(reader, binary.LittleEndian, &repeat)
+		binary.Read(reader, binary.LittleEndian, &frame.moveX)
+		binary.Read(reader, binary.LittleEndian, &frame.moveY)
+		frame.buttons, _ = reader.ReadByte()
+		if len(replay.frames)+int(repeat) > replayMaxFrames {
+			return replay, fmt.Errorf("%w: more than %d frames", errReplayCorrupt, replayMaxFrames)
+		}
+		for range repeat {
+			replay.frames = append(replay.frames, frame)
+		}
+	}
+
+	var checkpointCount uint32
+	binary.Read(reader, binary.LittleEndian, &checkpointCount)
+	if int64(checkpointCount)*8 > int64(reader.Len()) {
+		return replay, errReplayCorrupt
+	}
+	replay.checkpoints = make([]ReplayCheckpoint, checkpointCount)
+	for i := range replay.checkpoints {
+		binary.Read(reader, binary.LittleEndian, &replay.checkpoints[i].frame)
+		binary.Read(reader, binary.LittleEndian, &replay.checkpoints[i].hash)
+	}
+	for _, field := range replay.final.fields() {
+		if binary.Read(reader, binary.LittleEndian, field) != nil {
+			return replay, errReplayCorrupt
+		}
+	}
+	return replay, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+)
+
+/* a few seconds of play with runs of held input, and checkpoints to go with it */
+func testReplay() Replay {
+	replay := Replay{gameVersion: gameVersion, seed: 271828}
+	for i := range 600 {
+		frame := ReplayFrame{moveX: int8(i/40%3-1) * 127}
+		if i%90 < 5 {
+			frame.buttons |= replayButtonAction
+		}
+		replay.frames = append(replay.frames, frame)
+		if i%replayCheckpointInterval == 0 {
+			replay.checkpoints = append(replay.checkpoints, ReplayCheckpoint{frame: uint32(i), hash: uint32(i) * 2654435761})
+		}
+	}
+	replay.final = ReplaySummary{playTime: 10, y: 291234.5, hp: 2, hits: 1}
+	return replay
+}
+
+func TestDecodeReplay(t *testing.T) {
+	encoded := encodeReplay(testReplay())
+	tests := []struct {
+		name string
+		data func() []byte
+		ok   bool
+	}{
+		{"round trip", func() []byte { return encoded }, true},
+		{"flipped byte", func() []byte {
+			data := append([]byte{}, encoded...)
+			data[len(data)/2] ^= 0x40
+			return data
+		}, false},
+		{"truncated", func() []byte { return encoded[:len(encoded)-7] }, false},
+		{"truncated with a fresh checksum", func() []byte { return resealed(encoded[:len(encoded)-7]) }, false},
+		{"header only", func() []byte { return encoded[:8] }, false},
+		{"empty", func() []byte { return nil }, false},
+		{"newer version", func() []byte {
+			data := append([]byte{}, encoded...)
+			binary.LittleEndian.PutUint16(data[4:], replayVersion+1)
+			return resealed(data)
+		}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoded, err := decodeReplay(test.data())
+			if test.ok {
+				if err != nil {
+					t.Fatalf("failed to decode: %v", err)
+				}
+				if !reflect.DeepEqual(decoded, testReplay()) {
+					t.Errorf("decoded replay doesn't match what was encoded")
+				}
+			} else if err == nil {
+				t.Errorf("decoded without an error")
+			}
+		})
+	}
+}
+
+/* old replays are deleted once there are more than replaysKept, the personal best stays */
+func TestSaveReplayPrunesOldest(t *testing.T) {
+	useTempDataDir(t)
+	if err := os.MkdirAll(replaysDir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	const old = replaysKept + 5
+	for i := range old {
+		name := fmt.Sprintf("%s20200101-0000%02d-1.replay", replaysDir(), i)
+		if err := os.WriteFile(name, encodeReplay(Replay{seed: 1}), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saveReplay(Replay{gameVersion: gameVersion, seed: 2}, true)
+	entries, _ := os.ReadDir(replaysDir())
+	if len(entries) != replaysKept+1 {
+		t.Errorf("%d files in the replays folder, want %d and best.replay", len(entries), replaysKept)
+	}
+	for i := range old {
+		name := fmt.Sprintf("%s20200101-0000%02d-1.replay", replaysDir(), i)
+		_, err := os.Stat(name)
+		if kept := err == nil; kept != (i > old-replaysKept) {
+			t.Errorf("replay %d of %d kept: %t", i, old, kept)
+		}
+	}
+	if _, err := loadReplay(replaysDir() + replayBestFilename); err != nil {
+		t.Errorf("best replay wasn't saved: %v", err)
+	}
+}
+
+/* a file that's otherwise fine can't ask for more frames than any run could have */
+func TestDecodeReplayCapsFrames(t *testing.T) {
+	replay := Replay{gameVersion: gameVersion, frames: make([]ReplayFrame, replayMaxFrames+1)}
+	if _, err := decodeReplay(encodeReplay(replay)); !errors.Is(err, errReplayCorrupt) {
+		t.Errorf("decoding %d frames gave %v, want it rejected", len(replay.frames), err)
+	}
+	replay.frames = replay.frames[:replayMaxFrames]
+	if _, err := decodeReplay(encodeReplay(replay)); err != nil {
+		t.Errorf("decoding %d frames gave %v", len(replay.frames), err)
+	}
+}
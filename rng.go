@@ -0,0 +1,35 @@
+package main
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// two streams from the same seed, so particles and shake can't change what spawns
+const rngStreamGameplay uint64 = 1
+const rngStreamCosmetic uint64 = 2
+
+// random seeds are kept short enough to read off the screen and type back in
+const rngRandomSeedMax uint64 = 1_000_000_000
+
+/* game owned random numbers, a plain value so it resets and copies along with the Game */
+type Rng struct {
+	pcg rand.PCG
+}
+
+func newRng(seed uint64, stream uint64) Rng {
+	return Rng{pcg: *rand.NewPCG(seed, stream)}
+}
+
+/* same contract as rl.GetRandomValue, both ends are included and their order doesn't matter */
+func (rng *Rng) value(min, max int32) int32 {
+	if min > max {
+		min, max = max, min
+	}
+	span := uint64(int64(max) - int64(min) + 1)
+	return int32(int64(min) + int64(rng.pcg.Uint64()%span))
+}
+
+func randomSeed() uint64 {
+	return uint64(time.Now().UnixNano()) % rngRandomSeedMax
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"os"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+type Scores struct {
+	fastestTime  float64
+	lowest       float32
+	fewestHits   int16
+	wins         int16
+	lastInitials [3]byte
+	boards       [boardCount]Leaderboard
+}
+
+var scores = Scores{}
+
+// set when the scores file exists but couldn't be read, shown on the menu
+var scoresLoadError error
+
+/*
+SCORES FILE FORMAT
+
+everything is little endian
+
+	magic    [4]byte  "IBSC"
+	version  uint16   scoresVersion at the time of writing
+	count    uint16   number of fields that follow
+	fields   count * { tag uint16, length uint32, data [length]byte }
+	checksum uint32   crc32 (IEEE) of every byte before it
+
+fields are looked up by tag, so a reader skips tags it doesn't know and leaves
+fields it can't find at their defaults. to add a field to Scores, give it a new
+tag in scoreFields and never reuse or change the meaning of an old one.
+
+version 0 is the old format, which was the raw bytes of the Scores struct.
+*/
+
+const scoresMagic = "IBSC"
+const scoresVersion uint16 = 1
+const scoresLegacySize = 16
+
+const scoreTagFastestTime uint16 = 1
+const scoreTagLowest uint16 = 2
+const scoreTagFewestHits uint16 = 3
+const scoreTagWins uint16 = 4
+const scoreTagLastInitials uint16 = 5
+const scoreTagBoardFastest uint16 = 6
+const scoreTagBoardLowest uint16 = 7
+const scoreTagBoardFewestHits uint16 = 8
+
+type scoreField struct {
+	tag    uint16
+	values func(s *Scores) []any // pointers to fixed size values inside s, written in order
+}
+
+var scoreFields = []scoreField{
+	{scoreTagFastestTime, func(s *Scores) []any { return []any{&s.fastestTime} }},
+	{scoreTagLowest, func(s *Scores) []any { return []any{&s.lowest} }},
+	{scoreTagFewestHits, func(s *Scores) []any { return []any{&s.fewestHits} }},
+	{scoreTagWins, func(s *Scores) []any { return []any{&s.wins} }},
+	{scoreTagLastInitials, func(s *Scores) []any { return []any{&s.lastInitials} }},
+	{scoreTagBoardFastest, func(s *Scores) []any { return s.boards[boardFastest].fields() }},
+	{scoreTagBoardLowest, func(s *Scores) []any { return s.boards[boardLowest].fields() }},
+	{scoreTagBoardFewestHits, func(s *Scores) []any { return s.boards[boardFewestHits].fields() }},
+}
+
+func fieldsSize(values []any) int {
+	size := 0
+	for _, value := range values {
+		size += binary.Size(value)
+	}
+	return size
+}
+
+var errScoresCorrupt = errors.New("scores file is corrupt")
+var errScoresTruncated = errors.New("scores file is truncated")
+
+func defaultScores() Scores {
+	return Scores{lowest: startingHeight, fastestTime: -1, fewestHits: -1, wins: 0, lastInitials: [3]byte{'A', 'A', 'A'}}
+}
+
+func scoresFilename() string {
+	return dataDir + "scores"
+}
+
+func loadScores() {
+	scores = defaultScores()
+	scoresLoadError = nil
+	importLegacyFile("scores")
+	filename := scoresFilename()
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		scoresLoadError = err
+		rl.TraceLog(rl.LogError, "Failed to read scores: %v", err)
+		return
+	}
+	if !bytes.HasPrefix(data, []byte(scoresMagic)) && len(data) == scoresLegacySize {
+		scores = decodeLegacyScores(data)
+		seedLeaderboards(&scores)
+		rl.TraceLog(rl.LogInfo, "Migrating scores file from the legacy format")
+		saveScores()
+		return
+	}
+	loaded, err := decodeScores(data)
+	if err != nil {
+		// keep the broken file around instead of overwriting it on the next save
+		scoresLoadError = err
+		rl.TraceLog(rl.LogError, "Failed to load scores: %v", err)
+		if renameErr := os.Rename(filename, filename+".bad"); renameErr == nil {
+			rl.TraceLog(rl.LogWarning, "Moved unreadable scores file to %s.bad", filename)
+		}
+		return
+	}
+	scores = loaded
+	seedLeaderboards(&scores)
+}
+
+func saveScores() {
+	err := writeFileAtomic(scoresFilename(), encodeScores(scores))
+	if err != nil {
+		rl.TraceLog(rl.LogError, "Failed to save scores to file: %v", err)
+	}
+}
+
+func encodeScores(s Scores) []byte {
+	buffer := bytes.Buffer{}
+	buffer.WriteString(scoresMagic)
+	binary.Write(&buffer, binary.LittleEndian, scoresVersion)
+	binary.Write(&buffer, binary.LittleEndian, uint16(len(scoreFields)))
+	for _, field := range scoreFields {
+		values := field.values(&s)
+		binary.Write(&buffer, binary.LittleEndian, field.tag)
+		binary.Write(&buffer, binary.LittleEndian, uint32(fieldsSize(values)))
+		for _, value := range values {
+			binary.Write(&buffer, binary.LittleEndian, value)
+		}
+	}
+	binary.Write(&buffer, binary.LittleEndian, crc32.ChecksumIEEE(buffer.Bytes()))
+	return buffer.Bytes()
+}
+
+func decodeScores(data []byte) (Scores, error) {
+	s := defaultScores()
+	headerSize := len(scoresMagic) + 2 + 2
+	if len(data) < headerSize+4 {
+		return s, errScoresTruncated
+	}
+	if !bytes.HasPrefix(data, []byte(scoresMagic)) {
+		return s, errScoresCorrupt
+	}
+	body := data[:len(data)-4]
+	checksum := binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != checksum {
+		return s, errScoresCorrupt
+	}
+	version := binary.LittleEndian.Uint16(body[4:])
+	if version > scoresVersion {
+		return s, fmt.Errorf("scores file is from a newer version (%d)", version)
+	}
+	count := binary.LittleEndian.Uint16(body[6:])
+	cursor := body[headerSize:]
+	for range count {
+		if len(cursor) < 6 {
+			return s, errScoresTruncated
+		}
+		tag := binary.LittleEndian.Uint16(cursor)
+		length := binary.LittleEndian.Uint32(cursor[2:])
+		cursor = cursor[6:]
+		if uint32(len(cursor)) < length {
+			return s, errScoresTruncated
+		}
+		fieldData := cursor[:length]
+		cursor = cursor[length:]
+		for _, field := range scoreFields {
+			if field.tag != tag {
+				continue
+			}
+			values := field.values(&s)
+			if fieldsSize(values) != len(fieldData) {
+				return s, fmt.Errorf("%w: field %d has length %d", errScoresCorrupt, tag, length)
+			}
+			reader := bytes.NewReader(fieldData)
+			for _, value := range values {
+				binary.Read(reader, binary.LittleEndian, value)
+			}
+		}
+	}
+	// the checksum only says the file is as written, the boards index their entries by count
+	for i, board := range s.boards {
+		if board.count < 0 || board.count > leaderboardSize {
+			return s, fmt.Errorf("%w: %s board has %d entries", errScoresCorrupt, boardNames[i], board.count)
+		}
+	}
+	return s, nil
+}
+
+// the legacy file is the in-memory Scores struct, which was only ever written on little endian machines
+func decodeLegacyScores(data []byte) Scores {
+	s := defaultScores()
+	s.fastestTime = math.Float64frombits(binary.LittleEndian.Uint64(data[0:]))
+	s.lowest = math.Float32frombits(binary.LittleEndian.Uint32(data[8:]))
+	s.fewestHits = int16(binary.LittleEndian.Uint16(data[12:]))
+	s.wins = int16(binary.LittleEndian.Uint16(data[14:]))
+	return s
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"os"
+	"testing"
+)
+
+/* a scores file with every field set to something other than its default */
+func testScores() Scores {
+	s := Scores{fastestTime: 93.5, lowest: 1200, fewestHits: 2, wins: 7, lastInitials: [3]byte{'I', 'J', 'W'}}
+	s.boards[boardFastest].insert(LeaderboardEntry{initials: [3]byte{'A', 'B', 'C'}, value: 93.5, date: 1700000000})
+	s.boards[boardFastest].insert(LeaderboardEntry{initials: [3]byte{'D', 'E', 'F'}, value: 120.25, date: 1700000100})
+	s.boards[boardLowest].insert(LeaderboardEntry{initials: [3]byte{'G', 'H', 'I'}, value: 1200, date: 1700000200})
+	s.boards[boardFewestHits].insert(LeaderboardEntry{initials: [3]byte{'J', 'K', 'L'}, value: 2, date: 1700000300})
+	return s
+}
+
+/* puts a fresh checksum on the end of data, so a test can change the contents and still get past it */
+func resealed(data []byte) []byte {
+	body := data[:len(data)-4]
+	return binary.LittleEndian.AppendUint32(append([]byte{}, body...), crc32.ChecksumIEEE(body))
+}
+
+func TestDecodeScores(t *testing.T) {
+	encoded := encodeScores(testScores())
+	tests := []struct {
+		name string
+		data func() []byte
+		ok   bool
+	}{
+		{"round trip", func() []byte { return encoded }, true},
+		{"flipped byte", func() []byte {
+			data := append([]byte{}, encoded...)
+			data[len(data)/2] ^= 0x40
+			return data
+		}, false},
+		{"truncated", func() []byte { return encoded[:len(encoded)-9] }, false},
+		{"header only", func() []byte { return encoded[:10] }, false},
+		{"empty", func() []byte { return nil }, false},
+		{"newer version", func() []byte {
+			data := append([]byte{}, encoded...)
+			binary.LittleEndian.PutUint16(data[4:], scoresVersion+1)
+			return resealed(data)
+		}, false},
+		{"board count out of range", func() []byte {
+			data := append([]byte{}, encoded...)
+			// the first board's count is right after its tag and length
+			s := testScores()
+			offset := len(scoresMagic) + 2 + 2
+			for _, field := range scoreFields[:scoreTagBoardFastest-1] {
+				offset += 6 + fieldsSize(field.values(&s))
+			}
+			binary.LittleEndian.PutUint32(data[offset+6:], leaderboardSize+1)
+			return resealed(data)
+		}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoded, err := decodeScores(test.data())
+			if test.ok {
+				if err != nil {
+					t.Fatalf("failed to decode: %v", err)
+				}
+				if decoded != testScores() {
+					t.Errorf("decoded %+v, want %+v", decoded, testScores())
+				}
+			} else if err == nil {
+				t.Errorf("decoded without an error")
+			}
+		})
+	}
+}
+
+/* the old 16 byte file is read, seeds the leaderboards and is written back in the new format */
+func TestLoadLegacyScores(t *testing.T) {
+	useTempDataDir(t)
+	legacy := binary.LittleEndian.AppendUint64(nil, math.Float64bits(88.5))
+	legacy = binary.LittleEndian.AppendUint32(legacy, math.Float32bits(900))
+	legacy = binary.LittleEndian.AppendUint16(legacy, 3)
+	legacy = binary.LittleEndian.AppendUint16(legacy, 4)
+	if err := os.WriteFile(scoresFilename(), legacy, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loadScores()
+	if scores.fastestTime != 88.5 || scores.lowest != 900 || scores.fewestHits != 3 || scores.wins != 4 {
+		t.Fatalf("migrated %+v", scores)
+	}
+	if board := scores.boards[boardFastest]; board.count != 1 || board.entries[0].value != 88.5 {
+		t.Errorf("fastest board seeded with %+v", board)
+	}
+	migrated := scores
+	data, _ := os.ReadFile(scoresFilename())
+	if reloaded, err := decodeScores(data); err != nil || reloaded != migrated {
+		t.Errorf("file wasn't rewritten in the new format: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+SCREEN
+
+the game and its menus are drawn at virtualWidth by virtualHeight into a render texture, which is scaled up or down
+to fit the window with bars on whichever sides are left over. the window can be resized or made fullscreen freely.
+
+the hud is drawn straight to the window on top, at the same scale so it reads the same size as the game. it isn't
+boxed in with the game though, each piece is anchored to a corner or edge of the window so it keeps to the edges of
+the screen on ultrawide and portrait displays alike.
+*/
+
+// the size the game is laid out at, and the window's size to start with
+const virtualWidth int32 = 600
+const virtualHeight int32 = 800
+
+// small enough to tile beside something else, big enough the text is still readable
+const windowMinWidth int32 = 300
+const windowMinHeight int32 = 400
+
+// the widest the hud spreads out, so on an ultrawide it isn't off in the corner of your eye
+const hudMaxAspect float32 = 16.0 / 9.0
+
+// how far hud pieces are kept in from the edges
+var hudMargin = rl.Vector2{X: 24, Y: 20}
+
+// where a piece of the hud sits, as a fraction across and down the hud
+var anchorTopLeft = rl.Vector2{X: 0, Y: 0}
+var anchorTop = rl.Vector2{X: 0.5, Y: 0}
+var anchorTopRight = rl.Vector2{X: 1, Y: 0}
+var anchorCenter = rl.Vector2{X: 0.5, Y: 0.5}
+
+type Screen struct {
+	target rl.RenderTexture2D
+	scale  float32      // window pixels per virtual pixel
+	view   rl.Rectangle // where the game's drawn in the window
+	hudX   float32      // where the hud starts in the window
+	hud    rl.Vector2   // the hud's size, in virtual pixels
+}
+
+var screen = Screen{}
+
+/* opens the window and makes the render target */
+func initScreen() {
+	rl.SetConfigFlags(rl.FlagWindowResizable)
+	rl.InitWindow(virtualWidth, virtualHeight, "iced birds")
+	rl.SetWindowMinSize(int(windowMinWidth), int(windowMinHeight))
+	screen.target = rl.LoadRenderTexture(virtualWidth, virtualHeight)
+	rl.SetTextureFilter(screen.target.Texture, rl.FilterBilinear)
+}
+
+/* the size of what the game's drawn on, the virtual size before there's a render target */
+func screenSize() (float32, float32) {
+	if screen.target.ID == 0 {
+		return float32(virtualWidth), float32(virtualHeight)
+	}
+	return float32(screen.target.Texture.Width), float32(screen.target.Texture.Height)
+}
+
+/* fits the game and the hud to the window as it is this frame */
+func updateScreen() {
+	width := float32(rl.GetScreenWidth())
+	height := float32(rl.GetScreenHeight())
+	targetWidth, targetHeight := screenSize()
+	screen.scale = min(width/targetWidth, height/targetHeight)
+	screen.view.Width = targetWidth * screen.scale
+	screen.view.Height = targetHeight * screen.scale
+	screen.view.X = (width - screen.view.Width) / 2
+	screen.view.Y = (height - screen.view.Height) / 2
+
+	hudWidth := max(screen.view.Width, min(width, height*hudMaxAspect))
+	screen.hudX = (width - hudWidth) / 2
+	screen.hud = rl.Vector2{X: hudWidth / screen.scale, Y: height / screen.scale}
+}
+
+/* draws the render target into the window, call outside of texture mode */
+func drawScreen() {
+	texture := screen.target.Texture
+	// render textures come out upside down
+	src := rl.Rectangle{X: 0, Y: 0, Width: float32(texture.Width), Height: -float32(texture.Height)}
+	rl.DrawTexturePro(texture, src, screen.view, rl.Vector2{X: 0, Y: 0}, 0, rl.White)
+}
+
+/* everything drawn until endHud is laid out in virtual pixels across the whole hud, see hudPosition */
+func beginHud() {
+	rl.BeginMode2D(rl.Camera2D{Offset: rl.Vector2{X: screen.hudX, Y: 0}, Zoom: screen.scale})
+}
+
+func endHud() {
+	rl.EndMode2D()
+}
+
+/* the top left corner of a hud piece of size, placed at anchor and kept hudMargin in from the edges it's against */
+func hudPosition(anchor rl.Vector2, size rl.Vector2) rl.Vector2 {
+	return rl.Vector2{
+		X: anchor.X*(screen.hud.X-size.X) + hudMargin.X*(1-2*anchor.X),
+		Y: anchor.Y*(screen.hud.Y-size.Y) + hudMargin.Y*(1-2*anchor.Y),
+	}
+}
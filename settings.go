@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+SETTINGS
+
+volumes and window options from the settings page, saved in the config file.
+muting drops the master volume to zero so every sound, music and slide stream goes quiet together.
+fullscreen is a borderless window the size of the monitor, which the game scales itself to, see screen.go.
+*/
+
+type Settings struct {
+	MasterVolume float32 `json:"masterVolume"`
+	MusicVolume  float32 `json:"musicVolume"`
+	SfxVolume    float32 `json:"sfxVolume"`
+	Muted        bool    `json:"muted"`
+	Fullscreen   bool    `json:"fullscreen"`
+	Vsync        bool    `json:"vsync"`
+}
+
+var settings = defaultSettings()
+
+func defaultSettings() Settings {
+	return Settings{MasterVolume: 1, MusicVolume: 1, SfxVolume: 1}
+}
+
+const settingsRowMaster int32 = 0
+const settingsRowMusic int32 = 1
+const settingsRowSfx int32 = 2
+const settingsRowMute int32 = 3
+const settingsRowFullscreen int32 = 4
+const settingsRowVsync int32 = 5
+const settingsRowCount int32 = 6
+
+var settingsLabels = [settingsRowCount]string{"Master volume", "Music", "Sound effects", "Mute", "Fullscreen", "Vsync"}
+
+// how much one press moves a slider
+const settingsVolumeStep float32 = 0.1
+
+/* menu clicks, effects and the slide loops all follow the sfx volume, and everything the master volume unless muted */
+func applyVolumes() {
+	if settings.Muted {
+		backend.setMasterVolume(0)
+	} else {
+		backend.setMasterVolume(settings.MasterVolume)
+	}
+	backend.setCategoryVolume(soundCategoryUI, settings.SfxVolume)
+	backend.setCategoryVolume(soundCategorySfx, settings.SfxVolume)
+	backend.setCategoryVolume(soundCategoryLoop, settings.SfxVolume)
+	backend.setCategoryVolume(soundCategoryMusic, settings.MusicVolume)
+}
+
+func applyWindowSettings() {
+	if settings.Fullscreen != rl.IsWindowState(rl.FlagBorderlessWindowedMode) {
+		rl.ToggleBorderlessWindowed()
+	}
+	if settings.Vsync {
+		rl.SetWindowState(rl.FlagVsyncHint)
+	} else {
+		rl.ClearWindowState(rl.FlagVsyncHint)
+	}
+}
+
+func toggleMute() {
+	settings.Muted = !settings.Muted
+	applyVolumes()
+	saveConfig()
+}
+
+func toggleFullscreen() {
+	settings.Fullscreen = !settings.Fullscreen
+	applyWindowSettings()
+	saveConfig()
+}
+
+/* SETTINGS PAGE */
+
+func updateSettingsPage(game *Game) {
+	pressed := menuMovePressed(game)
+	if pressed.Y != 0 {
+		game.settingsRow = min(settingsRowCount-1, max(0, game.settingsRow+int32(pressed.Y)))
+		backend.playSound(resources.click)
+	}
+
+	changed := false
+	slide := func(volume *float32) {
+		if pressed.X != 0 {
+			// snapped to the nearest step so float error never builds up
+			steps := rl.Clamp(float32(int32(*volume/settingsVolumeStep+0.5))+pressed.X, 0, 1/settingsVolumeStep)
+			*volume = steps * settingsVolumeStep
+			changed = true
+		}
+	}
+	toggle := func(value *bool) {
+		if pressed.X != 0 || game.input.action {
+			*value = !*value
+			changed = true
+		}
+	}
+	switch game.settingsRow {
+	case settingsRowMaster:
+		slide(&settings.MasterVolume)
+	case settingsRowMusic:
+		slide(&settings.MusicVolume)
+	case settingsRowSfx:
+		slide(&settings.SfxVolume)
+	case settingsRowMute:
+		toggle(&settings.Muted)
+	case settingsRowFullscreen:
+		toggle(&settings.Fullscreen)
+	case settingsRowVsync:
+		toggle(&settings.Vsync)
+	}
+	if changed {
+		applyVolumes()
+		applyWindowSettings()
+		saveConfig()
+		backend.playSound(resources.click)
+	}
+
+	if game.input.pause {
+		game.menuPage = menuPageMain
+		backend.playSound(resources.click)
+	}
+}
+
+func drawSettingsPage(game Game) {
+	panel := rl.Rectangle{X: 24, Y: 210, Width: float32(virtualWidth) - 48, Height: 290}
+	rl.DrawRectangleRec(rl.Rectangle{X: panel.X - 4, Y: panel.Y - 4, Width: panel.Width + 8, Height: panel.Height + 8}, rl.Black)
+	rl.DrawRectangleRec(panel, rl.White)
+
+	title := "Settings"
+	drawText(title, float32(virtualWidth)/2-measureText(title)/2, panel.Y+8)
+	x := panel.X + 12
+	right := panel.X + panel.Width - 12
+	y := panel.Y + 50
+	onOff := func(value bool) string {
+		if value {
+			return "on"
+		}
+		return "off"
+	}
+	for row := range settingsRowCount {
+		if row == game.settingsRow {
+			rl.DrawRectangleRec(rl.Rectangle{X: panel.X + 6, Y: y, Width: panel.Width - 12, Height: 26}, colorLightGrey)
+		}
+		drawText(settingsLabels[row], x, y)
+		var volume float32
+		switch row {
+		case settingsRowMaster:
+			volume = settings.MasterVolume
+		case settingsRowMusic:
+			volume = settings.MusicVolume
+		case settingsRowSfx:
+			volume = settings.SfxVolume
+		case settingsRowMute:
+			drawText(onOff(settings.Muted), right-measureText(onOff(settings.Muted)), y)
+		case settingsRowFullscreen:
+			drawText(onOff(settings.Fullscreen), right-measureText(onOff(settings.Fullscreen)), y)
+		case settingsRowVsync:
+			drawText(onOff(settings.Vsync), right-measureText(onOff(settings.Vsync)), y)
+		}
+		if row <= settingsRowSfx {
+			/* SLIDER */
+			percent := fmt.Sprintf("%d", int32(volume*100+0.5))
+			bar := rl.Rectangle{X: right - 200, Y: y + 7, Width: 150, Height: 12}
+			rl.DrawRectangleRec(rl.Rectangle{X: bar.X - 2, Y: bar.Y - 2, Width: bar.Width + 4, Height: bar.Height + 4}, rl.Black)
+			rl.DrawRectangleRec(bar, rl.White)
+			rl.DrawRectangleRec(rl.Rectangle{X: bar.X, Y: bar.Y, Width: bar.Width * volume, Height: bar.Height}, colorBlack)
+			drawText(percent, right-measureText(percent), y)
+		}
+		y += 30
+	}
+
+	hint := "left and right change a setting"
+	drawText(hint, float32(virtualWidth)/2-measureText(hint)/2, panel.Y+panel.Height-34)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+SLIDE AUDIO
+
+the slide loops follow the bear's speed. they get louder as it gets up to its wish speed, and louder and higher
+the closer that is to slideTopSpeed. steering crossfades from the center loop to the side loop by how hard the bear
+is turning. everything fades instead of cutting, and drops to nothing while the bear is being knocked back uphill.
+the bear never leaves the ground yet, if it ever can that should silence the loops the same way.
+
+while boosting, the side loop plays a second time underneath at slideBoostPitch. there's no boost recording of its
+own, this is only the same file pitched down, so a real one would need its own file in resources/audio.
+*/
+
+// how much of the way to its target each layer gets in a second
+const slideFadeSpeed float32 = 8
+
+// the speed the loops stop getting louder and higher at
+const slideTopSpeed float32 = 2500
+
+const slideBoostPitch float32 = 0.5
+
+// below this a layer that's fading out is stopped
+const slideSilence float32 = 0.001
+
+type SlideAudio struct {
+	center float32 // current volumes
+	side   float32
+	boost  float32
+	pitch  float32
+}
+
+func updateSlideAudio(game *Game, frameTime float32) {
+	player := game.player()
+	var center, side, boost float32
+	pitch := float32(1)
+	hurt := player.vy > 0 || player.hp <= 0
+	if !hurt {
+		speed := -player.vy
+		intensity := rl.Clamp(speed/slideTopSpeed, 0, 1)
+		// quiet while still getting back up to speed
+		effort := rl.Clamp(speed/max(1, player.wishSpeed), 0, 1)
+		loudness := effort * (0.4 + 0.6*intensity)
+		turn := rl.Clamp(abs(player.vx)/max(1, player.wishSpeed*2), 0, 1)
+		// equal power, so it doesn't dip halfway into a turn
+		center = loudness * float32(math.Cos(float64(turn)*math.Pi/2))
+		side = loudness * float32(math.Sin(float64(turn)*math.Pi/2))
+		pitch = 0.8 + 0.4*intensity
+	}
+	if player.boostTimer.time > 0 && player.hp > 0 {
+		boost = 1
+	}
+
+	slide := &game.slideAudio
+	fade := min(1, slideFadeSpeed*frameTime)
+	slide.center += (center - slide.center) * fade
+	slide.side += (side - slide.side) * fade
+	slide.boost += (boost - slide.boost) * fade
+	slide.pitch += (pitch - slide.pitch) * fade
+	updateSlideLayer(resources.slideCenter, center, slide.center, slide.pitch)
+	updateSlideLayer(resources.slideSide, side, slide.side, slide.pitch)
+	updateSlideLayer(resources.slideBoost, boost, slide.boost, slideBoostPitch)
+}
+
+/* only starts a layer that's meant to be heard, so one that was stopped isn't brought back just to fade out */
+func updateSlideLayer(music rl.Music, target float32, volume float32, pitch float32) {
+	playing := backend.isMusicPlaying(music)
+	if target > 0 && !playing {
+		backend.playMusic(music)
+	} else if target == 0 && volume < slideSilence && playing {
+		backend.stopMusic(music)
+	}
+	backend.setMusicVolume(music, volume)
+	backend.setMusicPitch(music, pitch)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+SPRITES
+
+sprites are listed in sprites.json in named groups, and like archetypes.json a copy in the -resources folder replaces
+the built in one.
+
+	name        what the code and archetypes.json call it
+	sprites     files in resources/sprites. for bear, penguin, health, icons and menu these are frames in a set order,
+	            everywhere else they're variants to pick from, so adding a file to a group is all it takes to use it
+
+archetypes can point at a group with spriteGroup instead of listing their own sprites.
+once everything's loaded, sprites that can't be found and files in resources/sprites nothing uses are both reported.
+*/
+
+const spritesFilename = "sprites.json"
+
+//go:embed sprites.json
+var defaultSpriteGroups []byte
+
+type requiredSpriteGroup struct {
+	name  string
+	count int // the fewest sprites the code can get by with
+}
+
+var requiredSpriteGroups = []requiredSpriteGroup{
+	{"background", 1},
+	{"bear", 10},
+	{"health", 3},
+	{"icons", 3},
+	{"menu", 2},
+	{"penguin", 4},
+	{"penguinIce", 1},
+	{"pole", 1},
+	{"snowball", 1},
+	{"tree", 1},
+}
+
+type SpriteGroup struct {
+	Name    string   `json:"name"`
+	Sprites []string `json:"sprites"`
+
+	// filled in once loaded
+	sources []AnimSource
+}
+
+var spriteGroups = map[string]*SpriteGroup{}
+
+/* reads the groups, but not the sprites in them, so it works without a window. has to come before loadArchetypes */
+func loadSpriteGroups() {
+	loadDefinitions(spritesFilename, defaultSpriteGroups, func(data []byte) error {
+		parsed, err := parseSpriteGroups(data)
+		if err == nil {
+			spriteGroups = parsed
+		}
+		return err
+	})
+	assignSpriteGroups()
+}
+
+func parseSpriteGroups(data []byte) (map[string]*SpriteGroup, error) {
+	list := []*SpriteGroup{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	parsed := map[string]*SpriteGroup{}
+	for _, group := range list {
+		if group.Name == "" {
+			return nil, errors.New("sprite group with no name")
+		}
+		if parsed[group.Name] != nil {
+			return nil, fmt.Errorf("sprite group %s is defined twice", group.Name)
+		}
+		if len(group.Sprites) == 0 {
+			return nil, fmt.Errorf("sprite group %s has no sprites", group.Name)
+		}
+		// stays blank without a window, but the count still has to match for the random picks
+		group.sources = make([]AnimSource, len(group.Sprites))
+		parsed[group.Name] = group
+	}
+	for _, required := range requiredSpriteGroups {
+		group := parsed[required.name]
+		if group == nil {
+			return nil, fmt.Errorf("sprite group %s is missing", required.name)
+		}
+		if len(group.Sprites) < required.count {
+			return nil, fmt.Errorf("sprite group %s needs %d sprites", required.name, required.count)
+		}
+	}
+	return parsed, nil
+}
+
+/* loads every group's sprites, needs the window */
+func loadSpriteResources() {
+	for _, group := range spriteGroups {
+		group.sources = makeAnimSources(group.Sprites)
+	}
+	assignSpriteGroups()
+}
+
+func assignSpriteGroups() {
+	resources.background = spriteGroups["background"].sources
+	resources.bear = spriteGroups["bear"].sources
+	resources.health = spriteGroups["health"].sources
+	resources.icons = spriteGroups["icons"].sources
+	resources.menu = spriteGroups["menu"].sources
+	resources.penguin = spriteGroups["penguin"].sources
+	resources.penguinIce = spriteGroups["penguinIce"].sources
+	resources.pole = spriteGroups["pole"].sources
+	resources.snowball = spriteGroups["snowball"].sources
+	resources.trees = spriteGroups["tree"].sources
+}
+
+/* every sprite the groups, archetypes and stages point at */
+func referencedSprites() []string {
+	referenced := []string{}
+	for _, group := range spriteGroups {
+		referenced = append(referenced, group.Sprites...)
+	}
+	for _, archetype := range archetypes {
+		referenced = append(referenced, archetype.Sprites...)
+		if archetype.IceSprite != "" {
+			referenced = append(referenced, archetype.IceSprite)
+		}
+	}
+	for _, stage := range stages {
+		if stage.Background != "" {
+			referenced = append(referenced, stage.Background)
+		}
+	}
+	slices.Sort(referenced)
+	return slices.Compact(referenced)
+}
+
+/* every file in resources/sprites, built in or in the -resources folder */
+func spriteFiles() []string {
+	files := []string{}
+	entries, _ := fs.ReadDir(embeddedResources, "resources/sprites")
+	if resources.dir != "" {
+		overrides, _ := os.ReadDir(resources.dir + "sprites")
+		entries = append(entries, overrides...)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files = append(files, entry.Name())
+		}
+	}
+	slices.Sort(files)
+	return slices.Compact(files)
+}
+
+/* warns about sprites that are pointed at but can't be found, and sprite files nothing points at */
+func reportSpriteFiles() {
+	referenced := referencedSprites()
+	files := spriteFiles()
+	for _, sprite := range referenced {
+		if _, found := slices.BinarySearch(files, sprite); !found {
+			rl.TraceLog(rl.LogWarning, "Sprite %s is used but missing", sprite)
+		}
+	}
+	for _, file := range files {
+		if _, found := slices.BinarySearch(referenced, file); !found {
+			rl.TraceLog(rl.LogWarning, "Sprite %s isn't used by anything", file)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+/*
+STAGES
+
+the hill is split into stages by altitude, listed in stages.json from the top down. like archetypes.json,
+a copy in the -resources folder replaces the built in one.
+
+	name          shown when the player reaches it
+	top, bottom   altitude in meters, leave bottom out on the last stage to have it go on forever
+	spawns        list of {archetype, cost, density}, spawned in this order
+	                cost is how far the camera has to travel for one, divided by density
+	                density is a list of values spread evenly from top to bottom, in between is interpolated.
+	                a stage with no bottom only uses the first value
+	skierInterval seconds between penguins
+	maxSkiers     most penguins on the hill at once
+	background    file in resources/sprites drawn behind everything, optional
+	music         track from music.json played while in this stage, run if left out
+
+points for every archetype pile up from the start of the run whether or not the current stage spawns it.
+*/
+
+const stagesFilename = "stages.json"
+
+//go:embed stages.json
+var defaultStages []byte
+
+type StageSpawn struct {
+	Archetype string    `json:"archetype"`
+	Cost      float32   `json:"cost"`
+	Density   []float32 `json:"density"`
+}
+
+type Stage struct {
+	Name          string       `json:"name"`
+	Top           float32      `json:"top"`
+	Bottom        *float32     `json:"bottom"`
+	Spawns        []StageSpawn `json:"spawns"`
+	SkierInterval float32      `json:"skierInterval"`
+	MaxSkiers     int          `json:"maxSkiers"`
+	Background    string       `json:"background"`
+	Music         string       `json:"music"`
+
+	// filled in once loaded
+	background rl.Texture2D
+}
+
+var stages = []*Stage{}
+
+var errUnknownArchetype = errors.New("isn't an archetype")
+
+// every archetype any stage spawns, in the order they first appear
+var stageArchetypes = []string{}
+
+/* needs the archetypes loaded first */
+func loadStages() {
+	loadDefinitions(stagesFilename, defaultStages, func(data []byte) error {
+		parsed, err := parseStages(data)
+		if errors.Is(err, errUnknownArchetype) && bytes.Equal(data, defaultStages) {
+			// the built in stages only spawn built in archetypes, so it's an archetypes.json in -resources that's short
+			rl.TraceLog(rl.LogError, "Failed to load %s%s, using the built in one: %v", resources.dir, archetypesFilename, err)
+			definitionErrors = append(definitionErrors, fmt.Errorf("%s: %w", archetypesFilename, err))
+			loadBuiltInArchetypes()
+			parsed, err = parseStages(data)
+		}
+		if err != nil {
+			return err
+		}
+		stages = parsed
+		stageArchetypes = stageArchetypes[:0]
+		for _, stage := range stages {
+			for _, spawn := range stage.Spawns {
+				if !slices.Contains(stageArchetypes, spawn.Archetype) {
+					stageArchetypes = append(stageArchetypes, spawn.Archetype)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func parseStages(data []byte) ([]*Stage, error) {
+	parsed := []*Stage{}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+	if len(parsed) == 0 {
+		return nil, errors.New("there are no stages")
+	}
+	for _, stage := range parsed {
+		if stage.Bottom != nil && *stage.Bottom >= stage.Top {
+			return nil, fmt.Errorf("stage %s has its bottom above its top", stage.Name)
+		}
+		if stage.SkierInterval < 0 || stage.MaxSkiers < 0 {
+			return nil, fmt.Errorf("stage %s has negative penguins", stage.Name)
+		}
+		for _, spawn := range stage.Spawns {
+			if archetypes[spawn.Archetype] == nil {
+				return nil, fmt.Errorf("stage %s spawns %s, which %w", stage.Name, spawn.Archetype, errUnknownArchetype)
+			}
+			if spawn.Cost <= 0 || len(spawn.Density) == 0 {
+				return nil, fmt.Errorf("stage %s needs a cost and density for %s", stage.Name, spawn.Archetype)
+			}
+		}
+	}
+	return parsed, nil
+}
+
+/* loads the backgrounds, needs the window */
+func loadStageResources() {
+	for _, stage := range stages {
+		if stage.Background != "" {
+			stage.background = makeAnimSources([]string{stage.Background})[0].texture
+		}
+	}
+}
+
+/* the stage covering altitude y, -1 if it falls in a gap between stages */
+func findStage(y float32) int32 {
+	for i, stage := range stages {
+		if y <= stage.Top*100 && (stage.Bottom == nil || y > *stage.Bottom*100) {
+			return int32(i)
+		}
+	}
+	if y > stages[0].Top*100 {
+		return 0
+	}
+	return -1
+}
+
+/* how much of an archetype spawns at altitude y */
+func (stage *Stage) density(spawn StageSpawn, y float32) float32 {
+	if len(spawn.Density) == 1 || stage.Bottom == nil {
+		return spawn.Density[0]
+	}
+	top := stage.Top * 100
+	bottom := *stage.Bottom * 100
+	t := rl.Clamp((top-y)/(top-bottom), 0, 1) * float32(len(spawn.Density)-1)
+	i := min(int(t), len(spawn.Density)-2)
+	return spawn.Density[i] + (spawn.Density[i+1]-spawn.Density[i])*(t-float32(i))
+}
+
+/* moves to whichever stage the player is in now, announcing it if it changed during a run */
+func updateStage(game *Game) {
+	player := game.player()
+	index := findStage(player.y)
+	if index < 0 || index == game.stage {
+		return
+	}
+	announce := game.stage >= 0 && player.hp > 0
+	game.stage = index
+	stage := stages[index]
+	game.skierTimer.max = stage.SkierInterval
+	if announce {
+		game.notificationText = stage.Name
+		game.notificationTimer.reset()
+	}
+	soundtrack.play(findTrack(stage.Music))
+}
+
+/* spends the points piled up for each archetype the current stage spawns */
+func directSpawns(game *Game) {
+	if game.stage < 0 {
+		return
+	}
+	stage := stages[game.stage]
+	y := game.camera.y - viewDistance
+	for _, spawn := range stage.Spawns {
+		density := stage.density(spawn, game.player().y)
+		if density <= 0 {
+			continue
+		}
+		cost := spawn.Cost / density
+		for game.spawnPoints[spawn.Archetype] > cost {
+			if spawnArchetype(spawn.Archetype, y, game.path, &game.entitys, &game.rng) {
+				game.spawnPoints[spawn.Archetype] -= cost
+			} else {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const dataDirEnv = "ICEDPINES_DATA_DIR"
+const dataDirName = "icedpines"
+
+// where scores and settings live, always ends in a separator like resources.dir
+var dataDir string
+
+/*
+resolves the per-user data directory, in order of preference:
+the -data flag, $ICEDPINES_DATA_DIR, $XDG_DATA_HOME/icedpines, ~/.local/share/icedpines.
+on windows the last two are replaced by the roaming app data folder.
+*/
+func resolveDataDir(flagValue string) string {
+	dir := flagValue
+	if dir == "" {
+		dir = os.Getenv(dataDirEnv)
+	}
+	if dir == "" {
+		if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+			dir = filepath.Join(xdg, dataDirName)
+		} else if runtime.GOOS == "windows" {
+			if config, err := os.UserConfigDir(); err == nil {
+				dir = filepath.Join(config, dataDirName)
+			}
+		} else if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".local", "share", dataDirName)
+		}
+	}
+	if dir == "" {
+		// nowhere sensible to put it, fall back to the working directory
+		dir = "."
+	}
+	dir, _ = filepath.Abs(dir)
+	return dir + string(filepath.Separator)
+}
+
+func initDataDir(flagValue string) {
+	dataDir = resolveDataDir(flagValue)
+	err := os.MkdirAll(dataDir, 0o755)
+	if err != nil {
+		rl.TraceLog(rl.LogError, "Failed to create data directory %s: %v", dataDir, err)
+		return
+	}
+	rl.TraceLog(rl.LogInfo, "Using data directory %s", dataDir)
+}
+
+/* the resources folder next to the executable, where older versions kept everything */
+func legacyDir() string {
+	dir, _ := filepath.Abs(filepath.Dir(os.Args[0]))
+	return dir + string(filepath.Separator) + "resources" + string(filepath.Separator)
+}
+
+/* copies a file left next to the executable by older versions into the data directory, if there isn't one already */
+func importLegacyFile(name string) {
+	dst := dataDir + name
+	if _, err := os.Stat(dst); !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	data, err := os.ReadFile(legacyDir() + name)
+	if err != nil {
+		return
+	}
+	err = writeFileAtomic(dst, data)
+	if err != nil {
+		rl.TraceLog(rl.LogError, "Failed to import %s into the data directory: %v", name, err)
+		return
+	}
+	rl.TraceLog(rl.LogInfo, "Imported %s from %s", name, legacyDir())
+}
+
+/* writes to a temporary file next to filename and renames it over the top, so a crash never leaves half a file */
+func writeFileAtomic(filename string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tempName := temp.Name()
+	_, err = temp.Write(data)
+	if err == nil {
+		err = temp.Sync()
+	}
+	closeErr := temp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempName, 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tempName, filename)
+	}
+	if err != nil {
+		os.Remove(tempName)
+	}
+	return err
+}